@@ -0,0 +1,88 @@
+package cors
+
+import (
+	"net/http"
+	"sync"
+)
+
+// PathFunc returns the path the rule of the request is resolved by, such as
+// the route template of the request.
+type PathFunc func(r *http.Request) string
+
+// RequestPath returns the request URL path.
+func RequestPath(r *http.Request) string {
+	return r.URL.Path
+}
+
+// RulesHandler applies the rules of paths to requests, it is the base of
+// adapters of frameworks with their own handler types. Handlers of paths are
+// created once and reused.
+type RulesHandler struct {
+	rules *Rules
+	o     options
+
+	mu sync.RWMutex
+	hs map[string]*handler
+}
+
+// NewRulesHandler creates the handler of the parsed rules.
+func NewRulesHandler(rules *Rules, opts ...Option) *RulesHandler {
+	return &RulesHandler{
+		rules: rules,
+		o:     newOptions(opts...),
+		hs:    make(map[string]*handler),
+	}
+}
+
+// Serve applies the rule of the path to the request, the path is the route
+// template of the request. The request is passed to next unless the CORS
+// handler responds to it. Requests of paths without rules are passed to next
+// as is.
+func (rh *RulesHandler) Serve(path string, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	h := rh.handler(path)
+	if h == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// handlers are shared by requests, the copy has the request next handler
+	hh := *h
+	hh.next = next
+	hh.ServeHTTP(w, r)
+}
+
+// handler returns the handler of the path. Paths without explicit rules share
+// the handler of the wildcard rule, so the handlers of any request paths are
+// not kept.
+func (rh *RulesHandler) handler(path string) *handler {
+	key := path
+	if _, ok := rh.rules.pr[path]; !ok {
+		key = wildcard
+	}
+
+	rh.mu.RLock()
+	h, ok := rh.hs[key]
+	rh.mu.RUnlock()
+
+	if !ok {
+		if rule, found := rh.rules.pr[key]; found {
+			h = newHandler(key, rule, nil, rh.o)
+		}
+
+		rh.mu.Lock()
+		rh.hs[key] = h
+		rh.mu.Unlock()
+	}
+
+	if h == nil || key == path {
+		return h
+	}
+
+	// the wildcard handler copy of the path
+	hh := *h
+	hh.path = path
+	if rh.o.ro != nil {
+		hh.ro = newReportOnly(path, rh.o)
+	}
+	return &hh
+}
@@ -0,0 +1,77 @@
+package cors_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulesHandler(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;;PUT")
+	require.NoError(t, rules.Parse())
+
+	var decisions []cors.Decision
+	rh := cors.NewRulesHandler(rules, cors.DecisionObserver(cors.ObserverFunc(func(d cors.Decision) {
+		decisions = append(decisions, d)
+	})))
+
+	serve := func(path, method string, headers map[string]string, next string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/a/1", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		rh.Serve(path, rr, req, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, next)
+		}))
+		return rr
+	}
+
+	rr := serve("/a", http.MethodPut, map[string]string{"Origin": "https://foo.bar.org"}, "first")
+	assert.Equal(t, "https://foo.bar.org", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "first", rr.Body.String())
+
+	rr = serve("/a", http.MethodPut, map[string]string{"Origin": "https://foo.bar.org"}, "second")
+	assert.Equal(t, "https://foo.bar.org", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "second", rr.Body.String())
+
+	rr = serve("/a", http.MethodOptions, map[string]string{
+		"Origin":                        "https://foo.bar.org",
+		"Access-Control-Request-Method": "PUT",
+	}, "preflight")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	rr = serve("/b", http.MethodPut, map[string]string{"Origin": "https://foo.bar.org"}, "no rule")
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "no rule", rr.Body.String())
+
+	require.Len(t, decisions, 3)
+	for _, d := range decisions {
+		assert.Equal(t, "/a", d.Path)
+		assert.Equal(t, cors.OutcomeAllowed, d.Outcome)
+	}
+}
+
+func TestRulesHandlerWildcardPaths(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;;PUT\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+
+	var paths, rulePaths []string
+	rh := cors.NewRulesHandler(rules, cors.DecisionObserver(cors.ObserverFunc(func(d cors.Decision) {
+		paths = append(paths, d.Path)
+		rulePaths = append(rulePaths, d.RulePath)
+	})))
+
+	for _, p := range []string{"/a", "/b", "/c", "/b"} {
+		out := rh.Compute(cors.Input{Path: p, Method: http.MethodGet, Origin: "https://foo.bar.org"})
+		assert.Equal(t, cors.OutcomeAllowed, out.Decision.Outcome, p)
+	}
+	assert.Equal(t, []string{"/a", "/b", "/c", "/b"}, paths)
+	assert.Equal(t, []string{"/a", "*", "*", "*"}, rulePaths)
+}
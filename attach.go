@@ -0,0 +1,102 @@
+package cors
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RoutePath returns the path template of the gorilla mux route of the request,
+// the request URL path when the request has no route. It is used by
+// middleware added to the router with Use.
+func RoutePath(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			return t
+		}
+	}
+	return r.URL.Path
+}
+
+// Attach adds CORS to the routes of the router. Handlers of the routes are
+// wrapped with CORS middleware of the rules of their path templates and
+// preflight handlers are added for the templates. Routes without path
+// templates or rules are not changed.
+//
+// Attach must be called once, after all routes are added to the router.
+func Attach(router *mux.Router, r *Rules, opts ...Option) error {
+	o := newOptions(opts...)
+
+	var paths []string
+	routes := make(map[string][]*mux.Route)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			// subrouters routes are walked separately
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil // nolint: nilerr // the route does not match paths
+		}
+
+		if _, ok := r.OfPath(path); !ok {
+			return nil
+		}
+
+		if _, ok := routes[path]; !ok {
+			paths = append(paths, path)
+		}
+		routes[path] = append(routes[path], route)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// routes are changed after walking, the router routes must not change
+	// while walking
+	reg := MuxRegistrar(router)
+	for _, path := range paths {
+		rule, _ := r.OfPath(path)
+		if o.dm {
+			rule.m = routesMethods(rule.m, routes[path])
+		}
+
+		for _, route := range routes[path] {
+			h := newHandler(path, rule, route.GetHandler(), o)
+			h.rp = r.rulePath(path)
+			route.Handler(h)
+		}
+
+		h := newHandler(path, rule, o.next, o)
+		h.rp = r.rulePath(path)
+		reg.HandleOptions(path, h)
+	}
+
+	return nil
+}
+
+// routesMethods returns the methods served by the routes.
+func routesMethods(methods []string, routes []*mux.Route) []string {
+	served := make(map[string]bool)
+	for _, route := range routes {
+		rm, err := route.GetMethods()
+		if err != nil {
+			// the route serves any method
+			return methods
+		}
+
+		for _, m := range rm {
+			served[m] = true
+		}
+	}
+
+	var mm []string
+	for _, m := range methods {
+		if served[m] {
+			mm = append(mm, m)
+		}
+	}
+	return mm
+}
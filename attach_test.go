@@ -0,0 +1,203 @@
+package cors_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttach(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", ok).Methods(http.MethodGet, http.MethodPut)
+	router.HandleFunc("/users/{id}", ok).Methods(http.MethodDelete)
+	router.HandleFunc("/health", ok).Methods(http.MethodGet)
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/projects", ok).Methods(http.MethodPost)
+
+	rules := cors.NewRules("/users/{id};https://foo.bar.org;content-type;GET,PUT\n" +
+		"/api/projects;*;;POST")
+	require.NoError(t, rules.Parse())
+	require.NoError(t, cors.Attach(router, rules, cors.PreflightStatus(http.StatusNoContent)))
+
+	testCases := []struct {
+		desc    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		allow   map[string]string
+		body    string
+	}{
+		{
+			desc:   "answers preflight of route",
+			method: http.MethodOptions,
+			path:   "/users/1",
+			headers: map[string]string{
+				"Origin":                         "https://foo.bar.org",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			status: http.StatusNoContent,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":  "https://foo.bar.org",
+				"Access-Control-Allow-Methods": "PUT",
+				"Access-Control-Allow-Headers": "Content-Type",
+			},
+		},
+		{
+			desc:   "rejects preflight of method not allowed by rule",
+			method: http.MethodOptions,
+			path:   "/users/1",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusMethodNotAllowed,
+			allow:  map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			desc:    "sets headers of actual request",
+			method:  http.MethodGet,
+			path:    "/users/1",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusOK,
+			allow:   map[string]string{"Access-Control-Allow-Origin": "https://foo.bar.org"},
+			body:    "OK",
+		},
+		{
+			desc:    "sets headers of actual request of route with other methods",
+			method:  http.MethodDelete,
+			path:    "/users/1",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusOK,
+			allow:   map[string]string{"Access-Control-Allow-Origin": "https://foo.bar.org"},
+			body:    "OK",
+		},
+		{
+			desc:   "answers preflight of subrouter route",
+			method: http.MethodOptions,
+			path:   "/api/projects",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "POST",
+			},
+			status: http.StatusNoContent,
+			allow:  map[string]string{"Access-Control-Allow-Origin": "*"},
+		},
+		{
+			desc:    "does not change route without rule",
+			method:  http.MethodGet,
+			path:    "/health",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusOK,
+			allow:   map[string]string{"Access-Control-Allow-Origin": ""},
+			body:    "OK",
+		},
+		{
+			desc:   "does not answer preflight of route without rule",
+			method: http.MethodOptions,
+			path:   "/health",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "GET",
+			},
+			status: http.StatusMethodNotAllowed,
+			allow:  map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tC.method, tC.path, nil)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tC.status, rr.Code)
+			for k, v := range tC.allow {
+				assert.Equal(t, v, rr.Header().Get(k), k)
+			}
+			assert.Equal(t, tC.body, rr.Body.String())
+		})
+	}
+}
+
+func TestAttachDerivedMethods(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users", ok).Methods(http.MethodGet)
+	router.HandleFunc("/users", ok).Methods(http.MethodPost)
+	router.HandleFunc("/projects", ok).Methods(http.MethodGet, http.MethodDelete)
+	router.HandleFunc("/orders", ok)
+
+	rules := cors.NewRules("/projects;*;;GET,PUT\n*;*;;*")
+	require.NoError(t, rules.Parse())
+	require.NoError(t, cors.Attach(router, rules, cors.DerivedMethods()))
+
+	testCases := []struct {
+		desc   string
+		path   string
+		method string
+		status int
+	}{
+		{
+			desc:   "allows method of route",
+			path:   "/users",
+			method: http.MethodPost,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "does not allow method without route",
+			path:   "/users",
+			method: http.MethodPut,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:   "allows method of route allowed by rule",
+			path:   "/projects",
+			method: http.MethodGet,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "does not allow method of route not allowed by rule",
+			path:   "/projects",
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:   "does not allow method allowed by rule without route",
+			path:   "/projects",
+			method: http.MethodPut,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:   "allows methods of rule when route serves any method",
+			path:   "/orders",
+			method: http.MethodPatch,
+			status: http.StatusOK,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tC.path, nil)
+			req.Header.Set("Origin", "https://foo.bar.org")
+			req.Header.Set("Access-Control-Request-Method", tC.method)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tC.status, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Package chicors registers CORS preflight handlers in chi routers.
+package chicors
+
+import (
+	"net/http"
+
+	"github.com/antklim/cors"
+	"github.com/go-chi/chi/v5"
+)
+
+// Registrar returns the registrar of the chi router.
+func Registrar(r chi.Router) cors.Registrar {
+	return cors.RegistrarFunc(func(path string, h http.Handler) {
+		r.Method(http.MethodOptions, path, h)
+	})
+}
+
+// OptionsRoutes registers preflight handlers of the paths in the chi router,
+// see cors.RegisterOptionsRoutes.
+func OptionsRoutes(r chi.Router, paths []string, rules *cors.Rules, opts ...cors.Option) error {
+	return cors.RegisterOptionsRoutes(Registrar(r), paths, rules, opts...)
+}
@@ -0,0 +1,63 @@
+package chicors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/antklim/cors/chicors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsRoutes(t *testing.T) {
+	rules := cors.NewRules("/users/{id};https://foo.bar.org;content-type;PUT\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+
+	r := chi.NewRouter()
+	err := chicors.OptionsRoutes(r, []string{"/users/{id}", "/projects"}, rules)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc   string
+		path   string
+		method string
+		status int
+		origin string
+	}{
+		{
+			desc:   "answers preflight of path rule",
+			path:   "/users/1",
+			method: http.MethodPut,
+			status: http.StatusOK,
+			origin: "https://foo.bar.org",
+		},
+		{
+			desc:   "answers preflight of wildcard rule",
+			path:   "/projects",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			origin: "*",
+		},
+		{
+			desc:   "does not answer preflight of not registered path",
+			path:   "/orders",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tC.path, nil)
+			req.Header.Set("Origin", "https://foo.bar.org")
+			req.Header.Set("Access-Control-Request-Method", tC.method)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tC.status, rr.Code)
+			assert.Equal(t, tC.origin, rr.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
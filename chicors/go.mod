@@ -0,0 +1,19 @@
+module github.com/antklim/cors/chicors
+
+go 1.22
+
+require (
+	github.com/antklim/cors v0.0.0-00010101000000-000000000000
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/stretchr/testify v1.10.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/antklim/cors => ../
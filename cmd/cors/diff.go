@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/antklim/cors"
+)
+
+func diffCmd(args []string, stdout, stderr io.Writer) error {
+	fs, format := flagSet("diff", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 { // nolint: gomnd
+		fmt.Fprintln(fs.Output(), "usage: cors diff [flags] BEFORE AFTER")
+		fs.PrintDefaults()
+		return errUsage
+	}
+
+	before, err := loadRules(fs.Arg(0), *format)
+	if err != nil {
+		return err
+	}
+
+	after, err := loadRules(fs.Arg(1), *format)
+	if err != nil {
+		return err
+	}
+
+	printDiffs(stdout, cors.Diff(before, after))
+	return nil
+}
+
+func printDiffs(w io.Writer, diffs []cors.PathDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+
+	for _, d := range diffs {
+		path := d.Path
+		if path == "*" {
+			path = "* (paths without explicit rules)"
+		}
+
+		switch {
+		case !d.BeforeFound:
+			fmt.Fprintf(w, "%s: rule added\n", path)
+		case !d.AfterFound:
+			fmt.Fprintf(w, "%s: rule removed\n", path)
+		default:
+			fmt.Fprintf(w, "%s: rule changed\n", path)
+		}
+
+		if d.BeforeFound && d.AfterFound && d.BeforeWildcard != d.AfterWildcard {
+			fmt.Fprintf(w, "  ~ rule: %s -> %s\n", ruleKind(d.BeforeWildcard), ruleKind(d.AfterWildcard))
+		}
+
+		printValues(w, "+", "origin", d.AddedOrigins)
+		printValues(w, "-", "origin", d.RemovedOrigins)
+		printValues(w, "+", "header", d.AddedHeaders)
+		printValues(w, "-", "header", d.RemovedHeaders)
+		printValues(w, "+", "method", d.AddedMethods)
+		printValues(w, "-", "method", d.RemovedMethods)
+		printValues(w, "+", "exposed header", d.AddedExposed)
+		printValues(w, "-", "exposed header", d.RemovedExposed)
+
+		for _, s := range d.Settings {
+			fmt.Fprintf(w, "  ~ %s: %s -> %s\n", s.Name, s.Before, s.After)
+		}
+	}
+}
+
+func ruleKind(wildcard bool) string {
+	if wildcard {
+		return "wildcard"
+	}
+	return "explicit"
+}
+
+func printValues(w io.Writer, sign, name string, values []string) {
+	for _, v := range values {
+		fmt.Fprintf(w, "  %s %s %s\n", sign, name, v)
+	}
+}
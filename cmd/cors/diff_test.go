@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCmd(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			desc:   "reports no changes",
+			args:   []string{"diff", "testdata/rules.txt", "testdata/rules.yaml"},
+			code:   exitOK,
+			stdout: "no changes\n",
+		},
+		{
+			desc: "reports changes",
+			args: []string{"diff", "testdata/rules.txt", "testdata/rules_new.txt"},
+			code: exitOK,
+			stdout: "/users: rule changed\n" +
+				"  + origin https://partner.example.com\n" +
+				"  - origin https://admin.example.com\n" +
+				"  - method DELETE\n" +
+				"  ~ preflight status: default -> 204\n" +
+				"/projects: rule changed\n" +
+				"  ~ rule: explicit -> wildcard\n" +
+				"  + origin *\n" +
+				"  - origin https://admin.example.com\n" +
+				"  - origin https://app.example.com\n" +
+				"  - header Content-Type\n" +
+				"  + method POST\n" +
+				"  - method DELETE\n" +
+				"  - method PUT\n" +
+				"* (paths without explicit rules): rule changed\n" +
+				"  + method POST\n",
+		},
+		{
+			desc:   "fails with invalid config",
+			args:   []string{"diff", "testdata/rules.txt", "testdata/invalid.txt"},
+			code:   exitFail,
+			stderr: "testdata/invalid.txt: invalid cors rules: invalid HTTP method FOO in rule 1\n",
+		},
+		{
+			desc: "fails without after config",
+			args: []string{"diff", "testdata/rules.txt"},
+			code: exitUsage,
+			stderr: "usage: cors diff [flags] BEFORE AFTER\n" +
+				"  -format string\n    \tconfig format: txt, json, yaml (default by file extension)\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tC.args, &stdout, &stderr)
+			assert.Equal(t, tC.code, code)
+			assert.Equal(t, tC.stdout, stdout.String())
+			assert.Equal(t, tC.stderr, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/antklim/cors"
+)
+
+// issue is a risky setting of the path rule.
+type issue struct {
+	path string
+	msg  string
+}
+
+func (i issue) String() string {
+	return fmt.Sprintf("%s: %s", i.path, i.msg)
+}
+
+// lint returns risky settings of the parsed rules.
+func lint(rules *cors.Rules) []issue {
+	var issues []issue
+	for _, p := range rules.Paths() {
+		rule, _ := rules.OfPath(p)
+		for _, msg := range lintRule(rule) {
+			issues = append(issues, issue{path: p, msg: msg})
+		}
+	}
+	return issues
+}
+
+func lintRule(rule cors.Rule) []string {
+	var msgs []string
+
+	anyOrigin := len(rule.Origins()) == 0
+	if anyOrigin {
+		msgs = append(msgs, "empty origins allow any origin, use * to allow it explicitly")
+	}
+
+	for _, o := range rule.Origins() {
+		switch {
+		case o == "*":
+			anyOrigin = true
+			if len(rule.Origins()) > 1 {
+				msgs = append(msgs, "* allows any origin, other origins are redundant")
+			}
+		case o == "null":
+			msgs = append(msgs, "origin null is sent by sandboxed documents and local files, it can be forged")
+		default:
+			msgs = append(msgs, lintOrigin(o)...)
+		}
+	}
+
+	if anyOrigin && rule.AllowPrivateNetwork() {
+		msgs = append(msgs, "private network access is allowed from any origin")
+	}
+
+	if len(rule.Methods()) == 0 {
+		msgs = append(msgs, "no methods allowed, preflight requests always fail")
+	}
+
+	for _, m := range rule.Methods() {
+		if m == http.MethodConnect || m == http.MethodTrace {
+			msgs = append(msgs, fmt.Sprintf("method %s should not be allowed", m))
+		}
+	}
+
+	return msgs
+}
+
+func lintOrigin(o string) []string {
+	var msgs []string
+	err := cors.ValidateOrigin(o)
+	for _, e := range []error{cors.ErrOriginFormat, cors.ErrOriginCase} {
+		if errors.Is(err, e) {
+			msgs = append(msgs, fmt.Sprintf("origin %s never matches, %v", o, e))
+		}
+	}
+
+	u, err := url.Parse(o)
+	if err != nil {
+		return msgs
+	}
+
+	if u.Scheme == "http" && !isLoopback(u.Hostname()) {
+		msgs = append(msgs, fmt.Sprintf("origin %s is not secure, use https", o))
+	}
+
+	return msgs
+}
+
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,315 @@
+// Command cors validates, lints, explains and converts CORS rules configs.
+//
+// Usage:
+//
+//	cors validate [-format FORMAT] [-strict] FILE
+//	cors lint [-format FORMAT] FILE
+//	cors explain [-format FORMAT] -path PATH -origin ORIGIN -method METHOD [-headers HEADERS] [-private-network] FILE
+//	cors convert [-format FORMAT] -to FORMAT FILE
+//	cors serve [-format FORMAT] [-addr ADDR] [-paths PATHS] FILE
+//	cors diff [-format FORMAT] BEFORE AFTER
+//
+// FORMAT is one of txt, json, yaml. By default the format of FILE is
+// detected by its extension. The command exits with status 1 when the config
+// is invalid or has lint issues and with status 2 on usage errors.
+//
+// The validate command with -strict also rejects malformed origins and
+// headers, see Rules.ParseStrict.
+//
+// The serve command runs a local server answering preflights of the rules
+// paths and echoing actual requests, it prints CORS decision of each request.
+//
+// The diff command prints what changes in the rules applied to each path,
+// paths without explicit rules are resolved to the wildcard rule.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/antklim/cors"
+)
+
+const (
+	exitOK    = 0
+	exitFail  = 1
+	exitUsage = 2
+)
+
+const usage = `usage: cors <command> [flags] FILE
+
+commands:
+  validate  parse the config
+  lint      report risky settings of the config
+  explain   show CORS decision of a request
+  convert   convert the config to another format
+  serve     run local CORS test server
+  diff      show semantic changes between two configs
+`
+
+var errUsage = errors.New("usage error")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	commands := map[string]func([]string, io.Writer, io.Writer) error{
+		"validate": validateCmd,
+		"lint":     lintCmd,
+		"explain":  explainCmd,
+		"convert":  convertCmd,
+		"serve":    serveCmd,
+		"diff":     diffCmd,
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %s\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	if err := cmd(args[1:], stdout, stderr); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return exitUsage
+		}
+		fmt.Fprintln(stderr, err)
+		return exitFail
+	}
+
+	return exitOK
+}
+
+// flagSet creates flag set of the command with format flag.
+func flagSet(name string, stderr io.Writer) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "", "config format: txt, json, yaml (default by file extension)")
+	return fs, format
+}
+
+// parseArgs parses command flags and returns the config file name.
+func parseArgs(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(fs.Output(), "usage: cors %s [flags] FILE\n", fs.Name())
+		fs.PrintDefaults()
+		return "", errUsage
+	}
+
+	return fs.Arg(0), nil
+}
+
+// loadRules reads and parses the rules config file.
+func loadRules(name, format string) (*cors.Rules, error) {
+	rules, err := readRules(name, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rules.Parse(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return rules, nil
+}
+
+// readRules reads not parsed rules of the file.
+func readRules(name, format string) (*cors.Rules, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f := cors.Format(format)
+	if f == "" {
+		f = formatOf(name)
+	}
+
+	return cors.NewRulesWithFormat(string(b), f), nil
+}
+
+// formatOf detects the config format by the file extension.
+func formatOf(name string) cors.Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return cors.FormatJSON
+	case ".yaml", ".yml":
+		return cors.FormatYAML
+	default:
+		return cors.FormatTxt
+	}
+}
+
+func validateCmd(args []string, stdout, stderr io.Writer) error {
+	fs, format := flagSet("validate", stderr)
+	strict := fs.Bool("strict", false, "reject malformed origins and headers")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rules, err := readRules(name, *format)
+	if err != nil {
+		return err
+	}
+
+	parse := rules.Parse
+	if *strict {
+		parse = rules.ParseStrict
+	}
+
+	if err := parse(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	fmt.Fprintf(stdout, "%s: ok\n", name)
+	return nil
+}
+
+func lintCmd(args []string, stdout, stderr io.Writer) error {
+	fs, format := flagSet("lint", stderr)
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadRules(name, *format)
+	if err != nil {
+		return err
+	}
+
+	issues := lint(rules)
+	for _, i := range issues {
+		fmt.Fprintf(stdout, "%s: %s\n", name, i)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%s: %d issue(s) found", name, len(issues))
+	}
+
+	fmt.Fprintf(stdout, "%s: ok\n", name)
+	return nil
+}
+
+func explainCmd(args []string, stdout, stderr io.Writer) error {
+	fs, format := flagSet("explain", stderr)
+	path := fs.String("path", "", "request path")
+	origin := fs.String("origin", "", "request origin")
+	method := fs.String("method", http.MethodGet, "request method")
+	headers := fs.String("headers", "", "comma separated request headers")
+	pn := fs.Bool("private-network", false, "request to private network")
+
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *path == "" {
+		fmt.Fprintln(stderr, "path is required")
+		return errUsage
+	}
+
+	rules, err := loadRules(name, *format)
+	if err != nil {
+		return err
+	}
+
+	req := cors.ExplainRequest{
+		Path:           *path,
+		Origin:         *origin,
+		Method:         strings.ToUpper(*method),
+		PrivateNetwork: *pn,
+	}
+	if *headers != "" {
+		req.Headers = strings.Split(*headers, ",")
+	}
+
+	printExplanation(stdout, cors.Explain(rules, req))
+	return nil
+}
+
+func printExplanation(w io.Writer, e cors.Explanation) {
+	if e.Found {
+		fmt.Fprintf(w, "rule: origins=%s headers=%s methods=%s\n",
+			list(e.Rule.Origins()), list(e.Rule.Headers()), list(e.Rule.Methods()))
+	} else {
+		fmt.Fprintln(w, "rule: not found")
+	}
+
+	printResponse(w, "preflight", e.Preflight)
+	printResponse(w, "actual", e.Actual)
+}
+
+func printResponse(w io.Writer, name string, r cors.Response) {
+	fmt.Fprintf(w, "%s: %s", name, r.Decision.Outcome)
+	if r.Decision.Reason != cors.ReasonNone {
+		fmt.Fprintf(w, " (%s)", r.Decision.Reason)
+	}
+	if r.Status != 0 {
+		fmt.Fprintf(w, ", status %d", r.Status)
+	}
+	fmt.Fprintln(w)
+
+	keys := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range r.Header[k] {
+			fmt.Fprintf(w, "  %s: %s\n", k, v)
+		}
+	}
+}
+
+func list(l []string) string {
+	if len(l) == 0 {
+		return "-"
+	}
+	return strings.Join(l, ",")
+}
+
+func convertCmd(args []string, stdout, stderr io.Writer) error {
+	fs, format := flagSet("convert", stderr)
+	to := fs.String("to", "", "output format: txt, json, yaml")
+
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *to == "" {
+		fmt.Fprintln(stderr, "output format is required")
+		return errUsage
+	}
+
+	rules, err := loadRules(name, *format)
+	if err != nil {
+		return err
+	}
+
+	out, err := rules.Encode(cors.Format(*to))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(stdout, out)
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			desc:   "fails without command",
+			code:   exitUsage,
+			stderr: usage,
+		},
+		{
+			desc:   "fails with unknown command",
+			args:   []string{"foo"},
+			code:   exitUsage,
+			stderr: "unknown command foo\n" + usage,
+		},
+		{
+			desc:   "validates txt config",
+			args:   []string{"validate", "testdata/rules.txt"},
+			code:   exitOK,
+			stdout: "testdata/rules.txt: ok\n",
+		},
+		{
+			desc:   "validates json config",
+			args:   []string{"validate", "testdata/rules.json"},
+			code:   exitOK,
+			stdout: "testdata/rules.json: ok\n",
+		},
+		{
+			desc:   "validates yaml config",
+			args:   []string{"validate", "testdata/rules.yaml"},
+			code:   exitOK,
+			stdout: "testdata/rules.yaml: ok\n",
+		},
+		{
+			desc:   "validates config of explicit format",
+			args:   []string{"validate", "-format", "json", "testdata/rules.txt"},
+			code:   exitFail,
+			stderr: "testdata/rules.txt: invalid cors rules: invalid character '#' looking for beginning of value\n",
+		},
+		{
+			desc:   "fails validation of invalid config",
+			args:   []string{"validate", "testdata/invalid.txt"},
+			code:   exitFail,
+			stderr: "testdata/invalid.txt: invalid cors rules: invalid HTTP method FOO in rule 1\n",
+		},
+		{
+			desc:   "validates config in strict mode",
+			args:   []string{"validate", "-strict", "testdata/rules.txt"},
+			code:   exitOK,
+			stdout: "testdata/rules.txt: ok\n",
+		},
+		{
+			desc:   "fails strict validation of malformed config",
+			args:   []string{"validate", "-strict", "testdata/risky.txt"},
+			code:   exitFail,
+			stderr: "testdata/risky.txt: invalid cors rules: invalid origin \"https://App.example.com/\" in rule 1\n",
+		},
+		{
+			desc:   "fails validation of missing file",
+			args:   []string{"validate", "testdata/missing.txt"},
+			code:   exitFail,
+			stderr: "open testdata/missing.txt: no such file or directory\n",
+		},
+		{
+			desc: "fails validation without file",
+			args: []string{"validate"},
+			code: exitUsage,
+			stderr: "usage: cors validate [flags] FILE\n" +
+				"  -format string\n    \tconfig format: txt, json, yaml (default by file extension)\n" +
+				"  -strict\n    \treject malformed origins and headers\n",
+		},
+		{
+			desc:   "lints config without issues",
+			args:   []string{"lint", "testdata/rules.txt"},
+			code:   exitOK,
+			stdout: "testdata/rules.txt: ok\n",
+		},
+		{
+			desc: "lints risky config",
+			args: []string{"lint", "testdata/risky.txt"},
+			code: exitFail,
+			stdout: "testdata/risky.txt: /a: origin http://app.example.com is not secure, use https\n" +
+				"testdata/risky.txt: /a: origin https://App.example.com/ never matches, want scheme://host[:port]\n" +
+				"testdata/risky.txt: /a: origin https://App.example.com/ never matches, browsers send lower case origins\n" +
+				"testdata/risky.txt: /a: origin null is sent by sandboxed documents and local files, it can be forged\n" +
+				"testdata/risky.txt: /a: method TRACE should not be allowed\n" +
+				"testdata/risky.txt: /b: empty origins allow any origin, use * to allow it explicitly\n" +
+				"testdata/risky.txt: /b: private network access is allowed from any origin\n" +
+				"testdata/risky.txt: /b: no methods allowed, preflight requests always fail\n",
+			stderr: "testdata/risky.txt: 8 issue(s) found\n",
+		},
+		{
+			desc: "explains allowed request",
+			args: []string{"explain", "-path", "/users", "-origin", "https://app.example.com",
+				"-method", "put", "-headers", "content-type", "testdata/rules.yaml"},
+			code: exitOK,
+			stdout: "rule: origins=https://app.example.com,https://admin.example.com headers=content-type methods=GET,PUT,DELETE\n" +
+				"preflight: allowed, status 200\n" +
+				"  Access-Control-Allow-Headers: Content-Type\n" +
+				"  Access-Control-Allow-Methods: PUT\n" +
+				"  Access-Control-Allow-Origin: https://app.example.com\n" +
+				"  Vary: Origin, Access-Control-Request-Method, Access-Control-Request-Headers\n" +
+				"actual: allowed\n" +
+				"  Access-Control-Allow-Origin: https://app.example.com\n" +
+				"  Vary: Origin\n",
+		},
+		{
+			desc: "explains denied request",
+			args: []string{"explain", "-path", "/orders", "-origin", "https://app.example.com",
+				"-method", "DELETE", "testdata/rules.txt"},
+			code: exitOK,
+			stdout: "rule: origins=* headers=- methods=GET\n" +
+				"preflight: denied (method not allowed), status 405\n" +
+				"  Vary: Origin, Access-Control-Request-Method, Access-Control-Request-Headers\n" +
+				"actual: allowed\n" +
+				"  Access-Control-Allow-Origin: *\n",
+		},
+		{
+			desc:   "fails explain without path",
+			args:   []string{"explain", "testdata/rules.txt"},
+			code:   exitUsage,
+			stderr: "path is required\n",
+		},
+		{
+			desc:   "fails conversion of invalid config",
+			args:   []string{"convert", "-to", "json", "testdata/invalid.txt"},
+			code:   exitFail,
+			stderr: "testdata/invalid.txt: invalid cors rules: invalid HTTP method FOO in rule 1\n",
+		},
+		{
+			desc: "converts json config to txt",
+			args: []string{"convert", "-to", "txt", "testdata/rules.json"},
+			code: exitOK,
+			stdout: "/users;https://app.example.com,https://admin.example.com;content-type;GET,PUT,DELETE\n" +
+				"/projects;https://app.example.com,https://admin.example.com;content-type;GET,PUT,DELETE\n" +
+				"*;*;;GET\n",
+		},
+		{
+			desc: "converts txt config to yaml",
+			args: []string{"convert", "-to", "yaml", "testdata/rules.txt"},
+			code: exitOK,
+			stdout: `rules:
+    - paths:
+        - /users
+      origins:
+        - https://app.example.com
+        - https://admin.example.com
+      headers:
+        - content-type
+      methods:
+        - GET
+        - PUT
+        - DELETE
+    - paths:
+        - /projects
+      origins:
+        - https://app.example.com
+        - https://admin.example.com
+      headers:
+        - content-type
+      methods:
+        - GET
+        - PUT
+        - DELETE
+    - paths:
+        - '*'
+      origins:
+        - '*'
+      methods:
+        - GET
+`,
+		},
+		{
+			desc:   "fails conversion to unsupported format",
+			args:   []string{"convert", "-to", "toml", "testdata/rules.txt"},
+			code:   exitFail,
+			stderr: "unsupported format toml\n",
+		},
+		{
+			desc:   "fails conversion without output format",
+			args:   []string{"convert", "testdata/rules.txt"},
+			code:   exitUsage,
+			stderr: "output format is required\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tC.args, &stdout, &stderr)
+			assert.Equal(t, tC.code, code)
+			assert.Equal(t, tC.stdout, stdout.String())
+			assert.Equal(t, tC.stderr, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antklim/cors"
+	"github.com/gorilla/mux"
+)
+
+const readHeaderTimeout = 5 * time.Second
+
+// echo responds with the request method, path and headers.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Method string      `json:"method"`
+		Path   string      `json:"path"`
+		Header http.Header `json:"header"`
+	}{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header,
+	})
+})
+
+// decisionPrinter prints CORS decisions, one per line.
+type decisionPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *decisionPrinter) Observe(d cors.Decision) {
+	var sb strings.Builder
+	if d.Preflight {
+		sb.WriteString("preflight ")
+	}
+	fmt.Fprintf(&sb, "%s %s origin=%s", d.Method, d.Path, d.Origin)
+	if len(d.Headers) > 0 {
+		fmt.Fprintf(&sb, " headers=%s", strings.Join(d.Headers, ","))
+	}
+	fmt.Fprintf(&sb, ": %s", d.Outcome)
+	if d.Reason != cors.ReasonNone {
+		fmt.Fprintf(&sb, " (%s)", d.Reason)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(p.w, sb.String())
+}
+
+// newServer returns the handler answering preflights of the paths by the
+// rules and echoing actual requests with CORS headers.
+func newServer(rules *cors.Rules, paths []string, out io.Writer) (http.Handler, error) {
+	obs := cors.DecisionObserver(&decisionPrinter{w: out})
+
+	preflights, err := cors.OptionsRoutesOf(paths, rules, obs)
+	if err != nil {
+		return nil, err
+	}
+
+	router := mux.NewRouter()
+	router.Methods(http.MethodOptions).Handler(preflights)
+	for _, p := range paths {
+		rule, ok := rules.OfPath(p)
+		if !ok {
+			router.Handle(p, echo)
+			continue
+		}
+		router.Handle(p, cors.Middleware(p, rule, obs)(echo))
+	}
+
+	return router, nil
+}
+
+// serveCmd runs the CORS test server.
+func serveCmd(args []string, stdout, stderr io.Writer) error {
+	fs, format := flagSet("serve", stderr)
+	addr := fs.String("addr", "localhost:8080", "listen address")
+	paths := fs.String("paths", "", "comma separated paths to serve (default rules paths)")
+
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadRules(name, *format)
+	if err != nil {
+		return err
+	}
+
+	var pp []string
+	if *paths != "" {
+		pp = strings.Split(*paths, ",")
+	} else {
+		for _, p := range rules.Paths() {
+			if p != "*" {
+				pp = append(pp, p)
+			}
+		}
+	}
+
+	if len(pp) == 0 {
+		return errors.New("no paths to serve")
+	}
+
+	h, err := newServer(rules, pp, stdout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "serving %s on http://%s\n", strings.Join(pp, ","), *addr)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           h,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	rules, err := loadRules("testdata/rules.txt", "")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	h, err := newServer(rules, []string{"/users", "/orders"}, &out)
+	require.NoError(t, err)
+
+	// preflight
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "PUT", rr.Header().Get("Access-Control-Allow-Methods"))
+
+	// actual request
+	req = httptest.NewRequest(http.MethodPut, "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+
+	var body struct {
+		Method string      `json:"method"`
+		Path   string      `json:"path"`
+		Header http.Header `json:"header"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, http.MethodPut, body.Method)
+	assert.Equal(t, "/users", body.Path)
+	assert.Equal(t, "https://app.example.com", body.Header.Get("Origin"))
+
+	// denied preflight of wildcard rule
+	req = httptest.NewRequest(http.MethodOptions, "/orders", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	assert.Equal(t, "preflight PUT /users origin=https://app.example.com headers=Content-Type: allowed\n"+
+		"PUT /users origin=https://app.example.com: allowed\n"+
+		"preflight DELETE /orders origin=https://app.example.com: denied (method not allowed)\n", out.String())
+}
+
+func TestServerWithoutRule(t *testing.T) {
+	rules := cors.NewRules("/users;https://app.example.com;;GET")
+	require.NoError(t, rules.Parse())
+
+	var out bytes.Buffer
+	h, err := newServer(rules, []string{"/orders"}, &out)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, out.String())
+}
+
+func TestServeCmd(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"serve", "-paths", "", "testdata/invalid.txt"}, &stdout, &stderr)
+	assert.Equal(t, exitFail, code)
+	assert.Equal(t, "testdata/invalid.txt: invalid cors rules: invalid HTTP method FOO in rule 1\n", stderr.String())
+
+	stderr.Reset()
+	code = run([]string{"serve", "-addr", "localhost:-1", "testdata/rules.txt"}, &stdout, &stderr)
+	assert.Equal(t, exitFail, code)
+	assert.Equal(t, "serving /users,/projects on http://localhost:-1\n", stdout.String())
+	assert.Contains(t, stderr.String(), "invalid port")
+}
@@ -0,0 +1,124 @@
+package cors
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Input is the request CORS headers are computed for.
+type Input struct {
+	Path                  string // path the rule is resolved by, such as the route template
+	Method                string // request method, preflights are OPTIONS requests
+	Host                  string // request host, requests from the same origin are not cross-origin
+	Origin                string // Origin header
+	RequestMethod         string // Access-Control-Request-Method header, empty when not sent
+	RequestHeaders        string // Access-Control-Request-Headers header
+	RequestPrivateNetwork bool   // Access-Control-Request-Private-Network header is true
+}
+
+// Header is a response header.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Output is the result of applying CORS rule to the request.
+type Output struct {
+	Decision Decision
+	// Headers are CORS response headers, they exclude Vary.
+	Headers []Header
+	// Vary values must be added to the Vary header of the response, keeping
+	// the values set by the application.
+	Vary []string
+	// ShortCircuit reports whether the response is written with Status and
+	// without passing the request on, e.g. to preflights.
+	ShortCircuit bool
+	Status       int
+}
+
+// Compute applies the rule of the input path to the request without serving
+// it. It has no side effects: decision observers and report-only rules are
+// not called. Compute cannot call handlers, requests DisallowedHandler would
+// handle are short-circuited with 403.
+func Compute(rules *Rules, in Input, opts ...Option) Output {
+	rule, ok := rules.OfPath(in.Path)
+	if !ok {
+		return Output{Decision: noRuleDecision(in)}
+	}
+
+	o := newOptions(opts...)
+	o.obs = nil
+	o.ro = nil
+	h := newHandler(in.Path, rule, nil, o)
+	h.rp = rules.rulePath(in.Path)
+	return h.evaluate(in).output()
+}
+
+// Compute is Compute of the rules handler, it observes decisions and
+// evaluates report-only rules same as CORS handlers.
+func (rh *RulesHandler) Compute(in Input) Output {
+	h := rh.handler(in.Path)
+	if h == nil {
+		return Output{Decision: noRuleDecision(in)}
+	}
+
+	start := time.Now()
+	res := h.evaluate(in)
+	res.d.Duration = time.Since(start)
+	h.observe(res.d)
+	if h.ro != nil {
+		h.ro.compare(res.d, in)
+	}
+
+	return res.output()
+}
+
+// inputOf returns the input of the request to the path.
+func inputOf(path string, r *http.Request) Input {
+	return Input{
+		Path:                  path,
+		Method:                r.Method,
+		Host:                  r.Host,
+		Origin:                r.Header.Get(originHeader),
+		RequestMethod:         r.Header.Get(requestMethodHeader),
+		RequestHeaders:        r.Header.Get(requestHeadersHeader),
+		RequestPrivateNetwork: r.Header.Get(requestPrivateNetworkHeader) == "true",
+	}
+}
+
+// outputHeaders is the order of CORS headers in outputs.
+var outputHeaders = []string{
+	allowOriginHeader,
+	allowMethodsHeader,
+	allowHeadersHeader,
+	allowPrivateNetworkHeader,
+	exposeHeadersHeader,
+}
+
+func (res result) output() Output {
+	out := Output{
+		Decision:     res.d,
+		Vary:         res.vary,
+		ShortCircuit: res.status != 0 || res.handle,
+		Status:       res.status,
+	}
+
+	if res.handle {
+		out.Status = http.StatusForbidden
+	}
+
+	for _, name := range outputHeaders {
+		if v := res.header.Get(name); v != "" {
+			out.Headers = append(out.Headers, Header{Name: name, Value: v})
+		}
+	}
+
+	return out
+}
+
+// VaryValue returns the Vary header value of the output, it is empty when
+// the response does not vary.
+func (out Output) VaryValue() string {
+	return strings.Join(out.Vary, ", ")
+}
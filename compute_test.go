@@ -0,0 +1,227 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;content-type;PUT;private-network,expose=X-Request-Id\n" +
+		"/b;*;;GET;passthrough")
+	require.NoError(t, rules.Parse())
+
+	preflightVary := []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers",
+		"Access-Control-Request-Private-Network"}
+
+	testCases := []struct {
+		desc string
+		in   cors.Input
+		opts []cors.Option
+		out  cors.Output
+	}{
+		{
+			desc: "short-circuits allowed preflight",
+			in: cors.Input{
+				Path:                  "/a",
+				Method:                http.MethodOptions,
+				Origin:                "https://foo.bar.org",
+				RequestMethod:         http.MethodPut,
+				RequestHeaders:        "content-type",
+				RequestPrivateNetwork: true,
+			},
+			opts: []cors.Option{cors.PreflightStatus(http.StatusNoContent)},
+			out: cors.Output{
+				Headers: []cors.Header{
+					{Name: "Access-Control-Allow-Origin", Value: "https://foo.bar.org"},
+					{Name: "Access-Control-Allow-Methods", Value: "PUT"},
+					{Name: "Access-Control-Allow-Headers", Value: "Content-Type"},
+					{Name: "Access-Control-Allow-Private-Network", Value: "true"},
+				},
+				Vary:         preflightVary,
+				ShortCircuit: true,
+				Status:       http.StatusNoContent,
+			},
+		},
+		{
+			desc: "short-circuits rejected preflight",
+			in: cors.Input{
+				Path:          "/a",
+				Method:        http.MethodOptions,
+				Origin:        "https://foo.bar.org",
+				RequestMethod: http.MethodDelete,
+			},
+			out: cors.Output{
+				Vary:         preflightVary,
+				ShortCircuit: true,
+				Status:       http.StatusMethodNotAllowed,
+			},
+		},
+		{
+			desc: "short-circuits preflight without request method",
+			in: cors.Input{
+				Path:   "/a",
+				Method: http.MethodOptions,
+				Origin: "https://foo.bar.org",
+			},
+			out: cors.Output{
+				Vary:         preflightVary,
+				ShortCircuit: true,
+				Status:       http.StatusBadRequest,
+			},
+		},
+		{
+			desc: "passes through allowed preflight",
+			in: cors.Input{
+				Path:          "/b",
+				Method:        http.MethodOptions,
+				Origin:        "https://foo.bar.org",
+				RequestMethod: http.MethodGet,
+			},
+			out: cors.Output{
+				Headers: []cors.Header{{Name: "Access-Control-Allow-Origin", Value: "*"}},
+				Vary:    []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			},
+		},
+		{
+			desc: "passes on actual request",
+			in:   cors.Input{Path: "/a", Method: http.MethodPut, Origin: "https://foo.bar.org"},
+			out: cors.Output{
+				Headers: []cors.Header{
+					{Name: "Access-Control-Allow-Origin", Value: "https://foo.bar.org"},
+					{Name: "Access-Control-Expose-Headers", Value: "X-Request-Id"},
+				},
+				Vary: []string{"Origin"},
+			},
+		},
+		{
+			desc: "passes on actual request from disallowed origin",
+			in:   cors.Input{Path: "/a", Method: http.MethodPut, Origin: "https://bar.foo.org"},
+			out:  cors.Output{Vary: []string{"Origin"}},
+		},
+		{
+			desc: "short-circuits preflight from disallowed origin",
+			in: cors.Input{
+				Path:          "/a",
+				Method:        http.MethodOptions,
+				Origin:        "https://bar.foo.org",
+				RequestMethod: http.MethodPut,
+			},
+			out: cors.Output{Vary: preflightVary, ShortCircuit: true, Status: http.StatusOK},
+		},
+		{
+			desc: "rejects actual request from disallowed origin",
+			in:   cors.Input{Path: "/a", Method: http.MethodPut, Origin: "https://bar.foo.org"},
+			opts: []cors.Option{cors.RejectDisallowed()},
+			out:  cors.Output{Vary: []string{"Origin"}, ShortCircuit: true, Status: http.StatusForbidden},
+		},
+		{
+			desc: "rejects actual request disallowed handler would handle",
+			in:   cors.Input{Path: "/a", Method: http.MethodPut, Origin: "https://bar.foo.org"},
+			opts: []cors.Option{cors.DisallowedHandler(http.NotFoundHandler())},
+			out:  cors.Output{Vary: []string{"Origin"}, ShortCircuit: true, Status: http.StatusForbidden},
+		},
+		{
+			desc: "passes on same origin request",
+			in:   cors.Input{Path: "/a", Method: http.MethodPut, Origin: "https://bar.foo.org", Host: "bar.foo.org"},
+			opts: []cors.Option{cors.RejectDisallowed()},
+			out:  cors.Output{Vary: []string{"Origin"}},
+		},
+		{
+			desc: "passes on request to path without rule",
+			in:   cors.Input{Path: "/c", Method: http.MethodPut, Origin: "https://foo.bar.org"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			out := cors.Compute(rules, tC.in, tC.opts...)
+			assert.Equal(t, tC.out.Headers, out.Headers)
+			assert.Equal(t, tC.out.Vary, out.Vary)
+			assert.Equal(t, tC.out.ShortCircuit, out.ShortCircuit)
+			assert.Equal(t, tC.out.Status, out.Status)
+			assert.Equal(t, tC.in.Path, out.Decision.Path)
+		})
+	}
+}
+
+func TestComputeIsPure(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;;PUT")
+	require.NoError(t, rules.Parse())
+
+	observed := 0
+	obs := cors.ObserverFunc(func(cors.Decision) { observed++ })
+	rep := cors.ReporterFunc(func(cors.Mismatch) { observed++ })
+	in := cors.Input{Path: "/a", Method: http.MethodPut, Origin: "https://bar.foo.org"}
+
+	out := cors.Compute(rules, in, cors.DecisionObserver(obs), cors.ReportOnly(cors.NewRulesBuilder().Build(), rep))
+	assert.Equal(t, cors.OutcomeDenied, out.Decision.Outcome)
+	assert.Zero(t, observed)
+
+	rh := cors.NewRulesHandler(rules, cors.DecisionObserver(obs), cors.ReportOnly(cors.NewRulesBuilder().Build(), rep))
+	out = rh.Compute(in)
+	assert.Equal(t, cors.OutcomeDenied, out.Decision.Outcome)
+	assert.Equal(t, 2, observed)
+}
+
+func TestComputeMatchesMiddleware(t *testing.T) {
+	config := "/a;https://foo.bar.org;content-type;PUT;expose=X-Request-Id"
+	rules := cors.NewRules(config)
+	require.NoError(t, rules.Parse())
+	rule, _ := rules.OfPath("/a")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	h := cors.Middleware("/a", rule, cors.RejectDisallowed())(next)
+
+	requests := []map[string]string{
+		{"Origin": "https://foo.bar.org"},
+		{"Origin": "https://bar.foo.org"},
+		{},
+		{"Origin": "https://foo.bar.org", "Access-Control-Request-Method": "PUT", "Access-Control-Request-Headers": "content-type"},
+		{"Origin": "https://foo.bar.org", "Access-Control-Request-Method": "PUT", "Access-Control-Request-Headers": "x-id"},
+		{"Origin": "https://foo.bar.org", "Access-Control-Request-Method": "GET"},
+		{"Origin": "https://bar.foo.org", "Access-Control-Request-Method": "PUT"},
+	}
+	for _, headers := range requests {
+		method := http.MethodPut
+		if _, ok := headers["Access-Control-Request-Method"]; ok {
+			method = http.MethodOptions
+		}
+
+		req := httptest.NewRequest(method, "/a", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		out := cors.Compute(rules, cors.Input{
+			Path:           "/a",
+			Method:         method,
+			Host:           req.Host,
+			Origin:         headers["Origin"],
+			RequestMethod:  headers["Access-Control-Request-Method"],
+			RequestHeaders: headers["Access-Control-Request-Headers"],
+		}, cors.RejectDisallowed())
+
+		want := make(http.Header)
+		for _, hh := range out.Headers {
+			want.Set(hh.Name, hh.Value)
+		}
+		if v := out.VaryValue(); v != "" {
+			want.Set("Vary", v)
+		}
+		status := http.StatusAccepted
+		if out.ShortCircuit {
+			status = out.Status
+		}
+
+		assert.Equal(t, want, rr.Header(), headers)
+		assert.Equal(t, status, rr.Code, headers)
+	}
+}
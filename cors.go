@@ -4,23 +4,38 @@ import (
 	"errors"
 	"net/http"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
 
-// TODO: support different rules config format: yaml, json
-
 // CORS txt config format: ruleA\nruleB...\nruleX
 //
-// Rule format: PATHs;ORIGINs;HEADERs;METHODs
+// Everything after # is a comment. A line ending with \ continues on the
+// next line.
+//
+// Named sets of values can be defined as @name = valueA,valueB and referenced
+// by @name in ORIGINs, HEADERs and METHODs fields of rules, expose options
+// and other sets.
+//
+// Rule format: PATHs;ORIGINs;HEADERs;METHODs[;OPTIONs]
 // path can be *
 // allowed origins can be *
 // allowed headers should be explicit
 // allowed methods can be *
+// options are optional, supported options:
+//   private-network - allows requests to private network
+//   status=CODE - successful preflight response status, must be 2xx
+//   passthrough - passes preflights to the next handler
+//   expose=HEADER - exposes the header of actual responses, repeatable
+//
+// CORS json and yaml config formats (see NewRulesWithFormat) have the same
+// semantics:
+//   sets: {NAME: [VALUEs]}
+//   rules: [{paths, origins, headers, methods, exposedHeaders: [VALUEs],
+//            privateNetwork, preflightPassthrough: BOOL, preflightStatus: CODE}]
 
 var noopHTTPHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 
-func OptionsRoutes(paths []string, config string) (http.Handler, error) {
+func OptionsRoutes(paths []string, config string, opts ...Option) (http.Handler, error) {
 	if len(paths) == 0 {
 		return nil, errors.New("invalid paths list: cannot be empty")
 	}
@@ -30,26 +45,22 @@ func OptionsRoutes(paths []string, config string) (http.Handler, error) {
 		return nil, err
 	}
 
-	router := mux.NewRouter()
-
-	// r.op has only unique paths and a wildacrd if presented
-	// only the first occurrence of the path configuration applied
-	for _, p := range r.op {
-		rule := r.pr[p]
-		if p == wildcard {
-			for _, pp := range paths {
-				addOptionsRoute(router, pp, rule)
-			}
-			break
-		}
+	return optionsRoutes(paths, r, newOptions(opts...)), nil
+}
 
-		found, _ := find(paths, p)
-		if found {
-			addOptionsRoute(router, p, rule)
-		}
+// OptionsRoutesOf is OptionsRoutes of the parsed rules.
+func OptionsRoutesOf(paths []string, r *Rules, opts ...Option) (http.Handler, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("invalid paths list: cannot be empty")
 	}
 
-	return router, nil
+	return optionsRoutes(paths, r, newOptions(opts...)), nil
+}
+
+func optionsRoutes(paths []string, r *Rules, o options) http.Handler {
+	router := mux.NewRouter()
+	registerOptionsRoutes(MuxRegistrar(router), paths, r, o)
+	return router
 }
 
 func find(a []string, x string) (bool, int) {
@@ -61,19 +72,9 @@ func find(a []string, x string) (bool, int) {
 	return false, 0
 }
 
-func addOptionsRoute(router *mux.Router, path string, r Rule) {
-	h := handlers.CORS(
-		handlers.AllowedHeaders(r.Headers()),
-		handlers.AllowedMethods(r.Methods()),
-		handlers.AllowedOrigins(r.Origins()),
-	)(noopHTTPHandler)
-	router.Handle(path, h).Methods(http.MethodOptions)
-}
-
-func Middleware(path string, r Rule) func(http.Handler) http.Handler {
-	return handlers.CORS(
-		handlers.AllowedHeaders(r.Headers()),
-		handlers.AllowedMethods(r.Methods()),
-		handlers.AllowedOrigins(r.Origins()),
-	)
+func Middleware(path string, r Rule, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts...)
+	return func(h http.Handler) http.Handler {
+		return newHandler(path, r, h, o)
+	}
 }
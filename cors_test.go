@@ -166,6 +166,28 @@ func TestOptionsRoutes(t *testing.T) {
 	}
 }
 
+func TestOptionsRoutesOf(t *testing.T) {
+	rules := cors.NewRulesWithFormat(`{"rules": [{"paths": ["/a"], "origins": ["https://foo.bar.org"], "methods": ["PUT"]}]}`,
+		cors.FormatJSON)
+	require.NoError(t, rules.Parse())
+
+	_, err := cors.OptionsRoutesOf(nil, rules)
+	assert.EqualError(t, err, "invalid paths list: cannot be empty")
+
+	h, err := cors.OptionsRoutesOf([]string{"/a", "/b"}, rules)
+	require.NoError(t, err)
+
+	for path, code := range map[string]int{"/a": http.StatusOK, "/b": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://foo.bar.org")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, code, rr.Code, path)
+	}
+}
+
 func TestRouteMiddleware(t *testing.T) {
 	path := "/a"
 	mainHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -283,3 +305,313 @@ func TestRouteMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, res.StatusCode)
 	}
 }
+
+func TestPrivateNetwork(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		rules   string
+		headers map[string]string
+		want    string
+	}{
+		{
+			desc:  "allows private network when rule allows it",
+			rules: "/a;https://foo.bar.org;;PUT;private-network",
+			headers: map[string]string{
+				"Access-Control-Request-Private-Network": "true",
+			},
+			want: "true",
+		},
+		{
+			desc:  "does not allow private network when rule does not allow it",
+			rules: "/a;https://foo.bar.org;;PUT",
+			headers: map[string]string{
+				"Access-Control-Request-Private-Network": "true",
+			},
+		},
+		{
+			desc:  "does not allow private network when it is not requested",
+			rules: "/a;https://foo.bar.org;;PUT;private-network",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/a", nil)
+			req.Header.Set("Origin", "https://foo.bar.org")
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+
+			rr := httptest.NewRecorder()
+
+			h, err := cors.OptionsRoutes([]string{"/a"}, tC.rules)
+			require.NoError(t, err)
+			h.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tC.want, res.Header.Get("Access-Control-Allow-Private-Network"))
+		})
+	}
+}
+
+func TestPreflightOptions(t *testing.T) {
+	optionsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "OPTIONS, PUT")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	testCases := []struct {
+		desc  string
+		rules string
+		opts  []cors.Option
+		code  int
+		allow string
+	}{
+		{
+			desc:  "responds with 200 by default",
+			rules: "/a;*;;PUT",
+			code:  http.StatusOK,
+		},
+		{
+			desc:  "responds with globally configured status",
+			rules: "/a;*;;PUT",
+			opts:  []cors.Option{cors.PreflightStatus(http.StatusNoContent)},
+			code:  http.StatusNoContent,
+		},
+		{
+			desc:  "responds with rule status over global one",
+			rules: "/a;*;;PUT;status=202",
+			opts:  []cors.Option{cors.PreflightStatus(http.StatusNoContent)},
+			code:  http.StatusAccepted,
+		},
+		{
+			desc:  "ignores invalid global status",
+			rules: "/a;*;;PUT",
+			opts:  []cors.Option{cors.PreflightStatus(http.StatusFound)},
+			code:  http.StatusOK,
+		},
+		{
+			desc:  "passes preflight through when configured globally",
+			rules: "/a;*;;PUT",
+			opts: []cors.Option{
+				cors.PreflightPassthrough(),
+				cors.PreflightHandler(optionsHandler),
+			},
+			code:  http.StatusAccepted,
+			allow: "OPTIONS, PUT",
+		},
+		{
+			desc:  "passes preflight through when configured in rule",
+			rules: "/a;*;;PUT;passthrough",
+			opts:  []cors.Option{cors.PreflightHandler(optionsHandler)},
+			code:  http.StatusAccepted,
+			allow: "OPTIONS, PUT",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/a", nil)
+			req.Header.Set("Origin", "https://foo.bar.org")
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+
+			rr := httptest.NewRecorder()
+
+			h, err := cors.OptionsRoutes([]string{"/a"}, tC.rules, tC.opts...)
+			require.NoError(t, err)
+			h.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			assert.Equal(t, tC.code, res.StatusCode)
+			assert.Equal(t, "*", res.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "PUT", res.Header.Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, tC.allow, res.Header.Get("Allow"))
+		})
+	}
+}
+
+func TestMiddlewarePreflightPassthrough(t *testing.T) {
+	mainHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Method)
+	})
+
+	rule := cors.NewRuleBuilder().
+		WithOrigins("https://foo.bar.org").
+		WithMethods(http.MethodPut).
+		Build()
+
+	testCases := []struct {
+		desc    string
+		headers map[string]string
+		opts    []cors.Option
+		code    int
+		origin  string
+		body    string
+	}{
+		{
+			desc: "passes allowed preflight through",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			code:   http.StatusOK,
+			origin: "https://foo.bar.org",
+			body:   http.MethodOptions,
+		},
+		{
+			desc:    "passes options request without requested method through",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			code:    http.StatusOK,
+			origin:  "https://foo.bar.org",
+			body:    http.MethodOptions,
+		},
+		{
+			desc: "passes options request without origin through",
+			code: http.StatusOK,
+			body: http.MethodOptions,
+		},
+		{
+			desc: "passes preflight from disallowed origin through",
+			headers: map[string]string{
+				"Origin":                        "https://bar.foo.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			code: http.StatusOK,
+			body: http.MethodOptions,
+		},
+		{
+			desc: "rejects preflight from disallowed origin when configured",
+			headers: map[string]string{
+				"Origin":                        "https://bar.foo.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			opts: []cors.Option{cors.RejectDisallowed()},
+			code: http.StatusForbidden,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			opts := append([]cors.Option{cors.PreflightPassthrough()}, tC.opts...)
+			h := cors.Middleware("/a", rule, opts...)(mainHandler)
+
+			req := httptest.NewRequest(http.MethodOptions, "/a", nil)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			defer res.Body.Close()
+
+			body, _ := ioutil.ReadAll(res.Body)
+
+			assert.Equal(t, tC.code, res.StatusCode)
+			assert.Equal(t, tC.origin, res.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tC.body, string(body))
+		})
+	}
+}
+
+func TestDisallowedOrigin(t *testing.T) {
+	mainHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	})
+	disallowedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "disallowed")
+	})
+
+	testCases := []struct {
+		desc   string
+		method string
+		origin string
+		opts   []cors.Option
+		code   int
+		body   string
+	}{
+		{
+			desc:   "passes actual request by default",
+			method: http.MethodGet,
+			origin: "https://bar.foo.org",
+			code:   http.StatusOK,
+			body:   "OK",
+		},
+		{
+			desc:   "does not answer preflight by default",
+			method: http.MethodOptions,
+			origin: "https://bar.foo.org",
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "rejects actual request",
+			method: http.MethodGet,
+			origin: "https://bar.foo.org",
+			opts:   []cors.Option{cors.RejectDisallowed()},
+			code:   http.StatusForbidden,
+		},
+		{
+			desc:   "rejects preflight",
+			method: http.MethodOptions,
+			origin: "https://bar.foo.org",
+			opts:   []cors.Option{cors.RejectDisallowed()},
+			code:   http.StatusForbidden,
+		},
+		{
+			desc:   "does not reject request without origin",
+			method: http.MethodGet,
+			opts:   []cors.Option{cors.RejectDisallowed()},
+			code:   http.StatusOK,
+			body:   "OK",
+		},
+		{
+			desc:   "does not reject same origin request",
+			method: http.MethodGet,
+			origin: "http://example.com",
+			opts:   []cors.Option{cors.RejectDisallowed()},
+			code:   http.StatusOK,
+			body:   "OK",
+		},
+		{
+			desc:   "calls disallowed handler for actual request",
+			method: http.MethodGet,
+			origin: "https://bar.foo.org",
+			opts:   []cors.Option{cors.DisallowedHandler(disallowedHandler)},
+			code:   http.StatusUnauthorized,
+			body:   "disallowed",
+		},
+		{
+			desc:   "calls disallowed handler for preflight",
+			method: http.MethodOptions,
+			origin: "https://bar.foo.org",
+			opts:   []cors.Option{cors.DisallowedHandler(disallowedHandler)},
+			code:   http.StatusUnauthorized,
+			body:   "disallowed",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rule := cors.NewRuleBuilder().WithOrigins("https://foo.bar.org").WithMethods(http.MethodGet).Build()
+			h := cors.Middleware("/a", rule, tC.opts...)(mainHandler)
+
+			req := httptest.NewRequest(tC.method, "/a", nil)
+			if tC.origin != "" {
+				req.Header.Set("Origin", tC.origin)
+			}
+			req.Header.Set("Access-Control-Request-Method", "GET")
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			defer res.Body.Close()
+
+			body, _ := ioutil.ReadAll(res.Body)
+
+			assert.Equal(t, tC.code, res.StatusCode)
+			assert.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tC.body, string(body))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cors
+
+import "time"
+
+// Outcome is the result of applying CORS rule to a request.
+type Outcome string
+
+const (
+	OutcomeAllowed Outcome = "allowed" // CORS headers are set
+	OutcomeDenied  Outcome = "denied"  // CORS headers are not set or the request is rejected
+	OutcomeSkipped Outcome = "skipped" // not a cross-origin request
+)
+
+// Reason explains the outcome of the request.
+type Reason string
+
+const (
+	ReasonNone             Reason = ""
+	ReasonNoOrigin         Reason = "no origin"
+	ReasonSameOrigin       Reason = "same origin"
+	ReasonNoRule           Reason = "no rule"
+	ReasonOriginNotAllowed Reason = "origin not allowed"
+	ReasonNoRequestMethod  Reason = "no request method"
+	ReasonMethodNotAllowed Reason = "method not allowed"
+	ReasonHeaderNotAllowed Reason = "header not allowed"
+)
+
+// Decision describes how CORS rule is applied to a request.
+type Decision struct {
+	Path      string   // path the rule is applied to
+	RulePath  string   // path of the applied rule, * for the wildcard rule
+	Rule      Rule     // applied rule
+	Preflight bool     // the request is a preflight request
+	Origin    string   // request origin
+	Method    string   // requested method of preflights and request method otherwise
+	Headers   []string // requested headers of preflights
+	Outcome   Outcome
+	Reason    Reason
+	Duration  time.Duration // time spent evaluating the request, the next handler is not included
+}
+
+func (d *Decision) deny(r Reason) {
+	d.Outcome = OutcomeDenied
+	d.Reason = r
+}
+
+func (d *Decision) skip(r Reason) {
+	d.Outcome = OutcomeSkipped
+	d.Reason = r
+}
+
+// Observer receives the decision of each request handled by CORS handlers.
+type Observer interface {
+	Observe(Decision)
+}
+
+// ObserverFunc is an adapter to use functions as observers.
+type ObserverFunc func(Decision)
+
+func (f ObserverFunc) Observe(d Decision) {
+	f(d)
+}
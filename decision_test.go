@@ -0,0 +1,206 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionObserver(t *testing.T) {
+	rule := cors.NewRuleBuilder().
+		WithOrigins("https://foo.bar.org").
+		WithHeaders("content-type").
+		WithMethods(http.MethodPut).
+		Build()
+
+	testCases := []struct {
+		desc    string
+		method  string
+		headers map[string]string
+		want    cors.Decision
+	}{
+		{
+			desc:   "allowed actual request",
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+			want: cors.Decision{
+				Origin:  "https://foo.bar.org",
+				Method:  http.MethodPut,
+				Outcome: cors.OutcomeAllowed,
+			},
+		},
+		{
+			desc:   "allowed preflight",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://foo.bar.org",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type, accept",
+			},
+			want: cors.Decision{
+				Preflight: true,
+				Origin:    "https://foo.bar.org",
+				Method:    http.MethodPut,
+				Headers:   []string{"Content-Type", "Accept"},
+				Outcome:   cors.OutcomeAllowed,
+			},
+		},
+		{
+			desc:   "skipped request without origin",
+			method: http.MethodPut,
+			want: cors.Decision{
+				Method:  http.MethodPut,
+				Outcome: cors.OutcomeSkipped,
+				Reason:  cors.ReasonNoOrigin,
+			},
+		},
+		{
+			desc:   "skipped same origin request",
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "http://example.com",
+			},
+			want: cors.Decision{
+				Origin:  "http://example.com",
+				Method:  http.MethodPut,
+				Outcome: cors.OutcomeSkipped,
+				Reason:  cors.ReasonSameOrigin,
+			},
+		},
+		{
+			desc:   "denied origin",
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://bar.foo.org",
+			},
+			want: cors.Decision{
+				Origin:  "https://bar.foo.org",
+				Method:  http.MethodPut,
+				Outcome: cors.OutcomeDenied,
+				Reason:  cors.ReasonOriginNotAllowed,
+			},
+		},
+		{
+			desc:   "denied preflight without requested method",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+			want: cors.Decision{
+				Preflight: true,
+				Origin:    "https://foo.bar.org",
+				Outcome:   cors.OutcomeDenied,
+				Reason:    cors.ReasonNoRequestMethod,
+			},
+		},
+		{
+			desc:   "denied method",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			want: cors.Decision{
+				Preflight: true,
+				Origin:    "https://foo.bar.org",
+				Method:    http.MethodDelete,
+				Outcome:   cors.OutcomeDenied,
+				Reason:    cors.ReasonMethodNotAllowed,
+			},
+		},
+		{
+			desc:   "denied header",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://foo.bar.org",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "x-correlation-id",
+			},
+			want: cors.Decision{
+				Preflight: true,
+				Origin:    "https://foo.bar.org",
+				Method:    http.MethodPut,
+				Headers:   []string{"X-Correlation-Id"},
+				Outcome:   cors.OutcomeDenied,
+				Reason:    cors.ReasonHeaderNotAllowed,
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var got []cors.Decision
+			obs := cors.ObserverFunc(func(d cors.Decision) {
+				d.Duration = 0
+				got = append(got, d)
+			})
+
+			h := cors.Middleware("/a", rule, cors.DecisionObserver(obs))(http.NotFoundHandler())
+
+			req := httptest.NewRequest(tC.method, "/a", nil)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			tC.want.Path = "/a"
+			tC.want.RulePath = "/a"
+			tC.want.Rule = rule
+			assert.Equal(t, []cors.Decision{tC.want}, got)
+		})
+	}
+}
+
+func TestOptionsRoutesDecisionObserver(t *testing.T) {
+	var got []cors.Decision
+	obs := cors.ObserverFunc(func(d cors.Decision) {
+		got = append(got, d)
+	})
+
+	h, err := cors.OptionsRoutes([]string{"/a", "/b"}, "/a;foo.com;;PUT\n*;;;*", cors.DecisionObserver(obs))
+	require.NoError(t, err)
+
+	for _, path := range []string{"/a", "/b"} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://foo.bar.org")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "/a", got[0].Path)
+	assert.Equal(t, []string{"foo.com"}, got[0].Rule.Origins())
+	assert.Equal(t, cors.OutcomeDenied, got[0].Outcome)
+	assert.Equal(t, cors.ReasonOriginNotAllowed, got[0].Reason)
+	assert.Equal(t, "/b", got[1].Path)
+	assert.Nil(t, got[1].Rule.Origins())
+	assert.Equal(t, cors.OutcomeAllowed, got[1].Outcome)
+}
+
+func TestDecisionDurationExcludesNextHandler(t *testing.T) {
+	var got []cors.Decision
+	obs := cors.ObserverFunc(func(d cors.Decision) {
+		got = append(got, d)
+	})
+
+	const delay = 50 * time.Millisecond
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+	})
+
+	rule := cors.NewRuleBuilder().WithOrigins("*").WithMethods(http.MethodGet).Build()
+	h := cors.Middleware("/a", rule, cors.DecisionObserver(obs))(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	req.Header.Set("Origin", "https://foo.bar.org")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, got, 1)
+	assert.Less(t, got[0].Duration, delay)
+}
@@ -0,0 +1,163 @@
+package cors
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SettingChange is a change of the rule setting.
+type SettingChange struct {
+	Name   string
+	Before string
+	After  string
+}
+
+// PathDiff describes the changes of CORS rule applied to a path.
+// Path * describes the changes of the paths without explicit rules.
+type PathDiff struct {
+	Path           string
+	Before         Rule
+	After          Rule
+	BeforeFound    bool // before rules have a rule of the path
+	AfterFound     bool // after rules have a rule of the path
+	BeforeWildcard bool // before rule of the path is the wildcard rule
+	AfterWildcard  bool // after rule of the path is the wildcard rule
+	AddedOrigins   []string
+	RemovedOrigins []string
+	AddedHeaders   []string
+	RemovedHeaders []string
+	AddedMethods   []string
+	RemovedMethods []string
+	AddedExposed   []string // added exposed headers
+	RemovedExposed []string // removed exposed headers
+	Settings       []SettingChange
+}
+
+// Changed reports whether the rule applied to the path is changed.
+func (d PathDiff) Changed() bool {
+	return d.BeforeFound != d.AfterFound ||
+		d.BeforeWildcard != d.AfterWildcard ||
+		len(d.AddedOrigins) > 0 || len(d.RemovedOrigins) > 0 ||
+		len(d.AddedHeaders) > 0 || len(d.RemovedHeaders) > 0 ||
+		len(d.AddedMethods) > 0 || len(d.RemovedMethods) > 0 ||
+		len(d.AddedExposed) > 0 || len(d.RemovedExposed) > 0 ||
+		len(d.Settings) > 0
+}
+
+// Diff compares the rules applied to the paths of the parsed rules, paths
+// are resolved with OfPath. It returns the changed paths in order of before
+// paths followed by new after paths, the wildcard path is the last.
+func Diff(before, after *Rules) []PathDiff {
+	var paths []string
+	for _, p := range append(append([]string{}, before.Paths()...), after.Paths()...) {
+		if p != wildcard && !contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	paths = append(paths, wildcard)
+
+	var diffs []PathDiff
+	for _, p := range paths {
+		if d := diffPath(p, before, after); d.Changed() {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+func diffPath(path string, before, after *Rules) PathDiff {
+	d := PathDiff{Path: path}
+	d.Before, d.BeforeFound = before.OfPath(path)
+	d.After, d.AfterFound = after.OfPath(path)
+	d.BeforeWildcard = d.BeforeFound && isWildcardRule(before, path)
+	d.AfterWildcard = d.AfterFound && isWildcardRule(after, path)
+
+	d.AddedOrigins, d.RemovedOrigins = diffValues(originsOf(d.Before, d.BeforeFound), originsOf(d.After, d.AfterFound))
+	d.AddedHeaders, d.RemovedHeaders = diffValues(canonicalHeaders(d.Before.h), canonicalHeaders(d.After.h))
+	d.AddedExposed, d.RemovedExposed = diffValues(canonicalHeaders(d.Before.e), canonicalHeaders(d.After.e))
+	d.AddedMethods, d.RemovedMethods = diffValues(methodsOf(d.Before), methodsOf(d.After))
+
+	settings := []SettingChange{
+		{
+			Name:   "private network",
+			Before: strconv.FormatBool(d.Before.pn),
+			After:  strconv.FormatBool(d.After.pn),
+		},
+		{
+			Name:   "preflight status",
+			Before: preflightStatus(d.Before),
+			After:  preflightStatus(d.After),
+		},
+		{
+			Name:   "preflight passthrough",
+			Before: strconv.FormatBool(d.Before.pt),
+			After:  strconv.FormatBool(d.After.pt),
+		},
+	}
+	for _, s := range settings {
+		if s.Before != s.After {
+			d.Settings = append(d.Settings, s)
+		}
+	}
+
+	return d
+}
+
+// isWildcardRule reports whether the rule of the path is the wildcard rule.
+func isWildcardRule(r *Rules, path string) bool {
+	_, ok := r.pr[path]
+	return path == wildcard || !ok
+}
+
+// originsOf returns the allowed origins of the rule, any origin is *.
+func originsOf(r Rule, found bool) []string {
+	if !found {
+		return nil
+	}
+	if len(r.o) == 0 || contains(r.o, wildcard) {
+		return []string{wildcard}
+	}
+	return r.o
+}
+
+func canonicalHeaders(hh []string) []string {
+	h := make([]string, 0, len(hh))
+	for _, v := range hh {
+		h = append(h, http.CanonicalHeaderKey(strings.TrimSpace(v)))
+	}
+	return h
+}
+
+func methodsOf(r Rule) []string {
+	m := make([]string, 0, len(r.m))
+	for _, v := range r.m {
+		m = append(m, strings.ToUpper(strings.TrimSpace(v)))
+	}
+	return m
+}
+
+func preflightStatus(r Rule) string {
+	if r.status == 0 {
+		return "default"
+	}
+	return strconv.Itoa(r.status)
+}
+
+// diffValues returns sorted values added to and removed from the before list.
+func diffValues(before, after []string) (added, removed []string) {
+	for _, v := range after {
+		if !contains(before, v) && !contains(added, v) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range before {
+		if !contains(after, v) && !contains(removed, v) {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
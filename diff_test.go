@@ -0,0 +1,112 @@
+package cors_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(config string) *cors.Rules {
+		rules := cors.NewRules(config)
+		require.NoError(t, rules.Parse())
+		return rules
+	}
+
+	testCases := []struct {
+		desc   string
+		before string
+		after  string
+		assert func(*testing.T, []cors.PathDiff)
+	}{
+		{
+			desc:   "no changes when rules are semantically equal",
+			before: "/a,/b;https://a.com;content-type;PUT\n*;;;",
+			after:  "/b;https://a.com;Content-Type;put\n/a;https://a.com;content-type;PUT\n*;*;;",
+			assert: func(t *testing.T, diffs []cors.PathDiff) {
+				assert.Empty(t, diffs)
+			},
+		},
+		{
+			desc:   "reports added and removed values",
+			before: "/a;https://a.com,https://b.com;content-type;PUT,DELETE",
+			after:  "/a;https://a.com,https://c.com;x-correlation-id;PUT,PATCH;private-network,status=204",
+			assert: func(t *testing.T, diffs []cors.PathDiff) {
+				require.Len(t, diffs, 1)
+				d := diffs[0]
+				assert.Equal(t, "/a", d.Path)
+				assert.Equal(t, []string{"https://c.com"}, d.AddedOrigins)
+				assert.Equal(t, []string{"https://b.com"}, d.RemovedOrigins)
+				assert.Equal(t, []string{"X-Correlation-Id"}, d.AddedHeaders)
+				assert.Equal(t, []string{"Content-Type"}, d.RemovedHeaders)
+				assert.Equal(t, []string{http.MethodPatch}, d.AddedMethods)
+				assert.Equal(t, []string{http.MethodDelete}, d.RemovedMethods)
+				assert.Equal(t, []cors.SettingChange{
+					{Name: "private network", Before: "false", After: "true"},
+					{Name: "preflight status", Before: "default", After: "204"},
+				}, d.Settings)
+			},
+		},
+		{
+			desc:   "resolves paths through wildcard rule",
+			before: "/a;https://a.com;;PUT\n*;https://b.com;;GET",
+			after:  "/b;https://c.com;;GET\n*;https://b.com;;GET",
+			assert: func(t *testing.T, diffs []cors.PathDiff) {
+				require.Len(t, diffs, 2)
+
+				assert.Equal(t, "/a", diffs[0].Path)
+				assert.False(t, diffs[0].BeforeWildcard)
+				assert.True(t, diffs[0].AfterWildcard)
+				assert.Equal(t, []string{"https://b.com"}, diffs[0].AddedOrigins)
+				assert.Equal(t, []string{"https://a.com"}, diffs[0].RemovedOrigins)
+				assert.Equal(t, []string{http.MethodGet}, diffs[0].AddedMethods)
+				assert.Equal(t, []string{http.MethodPut}, diffs[0].RemovedMethods)
+
+				assert.Equal(t, "/b", diffs[1].Path)
+				assert.True(t, diffs[1].BeforeWildcard)
+				assert.False(t, diffs[1].AfterWildcard)
+				assert.Equal(t, []string{"https://c.com"}, diffs[1].AddedOrigins)
+				assert.Equal(t, []string{"https://b.com"}, diffs[1].RemovedOrigins)
+				assert.Empty(t, diffs[1].AddedMethods)
+			},
+		},
+		{
+			desc:   "reports added wildcard rule",
+			before: "/a;https://a.com;;PUT",
+			after:  "/a;https://a.com;;PUT\n*;;;GET",
+			assert: func(t *testing.T, diffs []cors.PathDiff) {
+				require.Len(t, diffs, 1)
+				d := diffs[0]
+				assert.Equal(t, "*", d.Path)
+				assert.False(t, d.BeforeFound)
+				assert.True(t, d.AfterFound)
+				assert.True(t, d.AfterWildcard)
+				assert.Equal(t, []string{"*"}, d.AddedOrigins)
+				assert.Equal(t, []string{http.MethodGet}, d.AddedMethods)
+			},
+		},
+		{
+			desc:   "reports removed path rule",
+			before: "/a;https://a.com;;PUT",
+			after:  "/b;https://a.com;;PUT",
+			assert: func(t *testing.T, diffs []cors.PathDiff) {
+				require.Len(t, diffs, 2)
+				assert.Equal(t, "/a", diffs[0].Path)
+				assert.True(t, diffs[0].BeforeFound)
+				assert.False(t, diffs[0].AfterFound)
+				assert.Equal(t, []string{"https://a.com"}, diffs[0].RemovedOrigins)
+				assert.Equal(t, "/b", diffs[1].Path)
+				assert.False(t, diffs[1].BeforeFound)
+				assert.True(t, diffs[1].AfterFound)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tC.assert(t, cors.Diff(parse(tC.before), parse(tC.after)))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Package echocors provides Echo middleware applying CORS rules.
+package echocors
+
+import (
+	"net/http"
+
+	"github.com/antklim/cors"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns the middleware applying the rules of route paths to
+// requests. Echo routes preflights of paths having routes of other methods,
+// so preflights do not need OPTIONS routes. Requests without routes are
+// passed as is.
+func Middleware(rules *cors.Rules, opts ...cors.Option) echo.MiddlewareFunc {
+	rh := cors.NewRulesHandler(rules, opts...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Path()
+			if path == "" {
+				return next(c)
+			}
+
+			var err error
+			rh.Serve(path, c.Response(), c.Request(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				c.SetResponse(echo.NewResponse(w, c.Echo()))
+				err = next(c)
+			}))
+			return err
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package echocors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/antklim/cors/echocors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	rules := cors.NewRules("/users/:id;https://foo.bar.org;content-type;GET,PUT\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+
+	e := echo.New()
+	e.Use(echocors.Middleware(rules, cors.PreflightStatus(http.StatusNoContent)))
+	e.GET("/users/:id", func(c echo.Context) error {
+		c.Response().Header().Set("Vary", "Accept-Encoding")
+		return c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+	e.PUT("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusAccepted)
+	})
+	e.GET("/projects", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot)
+	})
+
+	testCases := []struct {
+		desc    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		allow   map[string]string
+		vary    []string
+		body    string
+	}{
+		{
+			desc:   "answers preflight",
+			method: http.MethodOptions,
+			path:   "/users/1",
+			headers: map[string]string{
+				"Origin":                         "https://foo.bar.org",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			status: http.StatusNoContent,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":  "https://foo.bar.org",
+				"Access-Control-Allow-Methods": "PUT",
+				"Access-Control-Allow-Headers": "Content-Type",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "rejects preflight of method not allowed",
+			method: http.MethodOptions,
+			path:   "/users/1",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusMethodNotAllowed,
+			allow:  map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:   []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:    "sets headers of actual request",
+			method:  http.MethodGet,
+			path:    "/users/1",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusOK,
+			allow:   map[string]string{"Access-Control-Allow-Origin": "https://foo.bar.org"},
+			vary:    []string{"Accept-Encoding", "Origin"},
+			body:    "user 1",
+		},
+		{
+			desc:    "sets headers of actual request without body",
+			method:  http.MethodPut,
+			path:    "/users/1",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusAccepted,
+			allow:   map[string]string{"Access-Control-Allow-Origin": "https://foo.bar.org"},
+			vary:    []string{"Origin"},
+		},
+		{
+			desc:    "applies wildcard rule to route without explicit rule",
+			method:  http.MethodGet,
+			path:    "/projects",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusTeapot,
+			allow:   map[string]string{"Access-Control-Allow-Origin": "*"},
+			body:    "{\"message\":\"I'm a teapot\"}\n",
+		},
+		{
+			desc:    "passes request without route",
+			method:  http.MethodGet,
+			path:    "/orders",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusNotFound,
+			allow:   map[string]string{"Access-Control-Allow-Origin": ""},
+			body:    "{\"message\":\"Not Found\"}\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tC.method, tC.path, nil)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			assert.Equal(t, tC.status, rr.Code)
+			for k, v := range tC.allow {
+				assert.Equal(t, v, rr.Header().Get(k), k)
+			}
+			assert.Equal(t, tC.vary, rr.Header().Values("Vary"))
+			assert.Equal(t, tC.body, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package cors
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ExplainRequest describes a cross-origin request to explain.
+type ExplainRequest struct {
+	Path           string
+	Origin         string
+	Method         string   // method of the actual request
+	Headers        []string // headers of the actual request listed in preflight
+	PrivateNetwork bool     // the request is to private network
+}
+
+// Response is the CORS response to a request. Requests DisallowedHandler
+// handles have status 403 as in Compute.
+type Response struct {
+	Decision Decision
+	Status   int         // response status, 0 - the request is passed to the next handler
+	Handled  bool        // the request is passed to the disallowed handler
+	Header   http.Header // CORS response headers including Vary
+}
+
+// Explanation describes how rules are applied to a cross-origin request.
+type Explanation struct {
+	Rule        Rule
+	Found       bool     // rules have a rule of the path
+	PreflightOK bool     // preflight request succeeds
+	Preflight   Response // response to the preflight request
+	Actual      Response // response to the actual request
+}
+
+// Explain evaluates the parsed rules against the preflight and actual
+// requests without serving them.
+func Explain(rules *Rules, req ExplainRequest, opts ...Option) Explanation {
+	preflight := Input{
+		Path:                  req.Path,
+		Method:                http.MethodOptions,
+		Origin:                req.Origin,
+		RequestMethod:         req.Method,
+		RequestHeaders:        strings.Join(req.Headers, valuesDlm),
+		RequestPrivateNetwork: req.PrivateNetwork,
+	}
+
+	actual := Input{
+		Path:   req.Path,
+		Method: req.Method,
+		Origin: req.Origin,
+	}
+
+	rule, ok := rules.OfPath(req.Path)
+	if !ok {
+		return Explanation{
+			Preflight: Response{Decision: noRuleDecision(preflight), Header: make(http.Header)},
+			Actual:    Response{Decision: noRuleDecision(actual), Header: make(http.Header)},
+		}
+	}
+
+	o := newOptions(opts...)
+	o.ro = nil
+	h := newHandler(req.Path, rule, nil, o)
+	h.rp = rules.rulePath(req.Path)
+
+	e := Explanation{
+		Rule:      rule,
+		Found:     true,
+		Preflight: newResponse(h.evaluate(preflight)),
+		Actual:    newResponse(h.evaluate(actual)),
+	}
+	e.PreflightOK = e.Preflight.Decision.Outcome == OutcomeAllowed
+
+	return e
+}
+
+func newResponse(res result) Response {
+	out := res.output()
+	addVary(res.header, out.Vary...)
+	return Response{
+		Decision: out.Decision,
+		Status:   out.Status,
+		Handled:  res.handle,
+		Header:   res.header,
+	}
+}
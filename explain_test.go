@@ -0,0 +1,153 @@
+package cors_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	rules := cors.NewRules(`/a;https://foo.bar.org,https://bar.foo.org;content-type;PUT,GET;private-network
+		/b;*;;*;status=204`)
+	require.NoError(t, rules.Parse())
+
+	testCases := []struct {
+		desc   string
+		req    cors.ExplainRequest
+		assert func(*testing.T, cors.Explanation)
+	}{
+		{
+			desc: "explains allowed request",
+			req: cors.ExplainRequest{
+				Path:           "/a",
+				Origin:         "https://foo.bar.org",
+				Method:         http.MethodPut,
+				Headers:        []string{"content-type"},
+				PrivateNetwork: true,
+			},
+			assert: func(t *testing.T, e cors.Explanation) {
+				assert.True(t, e.Found)
+				assert.Equal(t, []string{"https://foo.bar.org", "https://bar.foo.org"}, e.Rule.Origins())
+				assert.True(t, e.PreflightOK)
+
+				assert.Equal(t, cors.OutcomeAllowed, e.Preflight.Decision.Outcome)
+				assert.Equal(t, http.StatusOK, e.Preflight.Status)
+				assert.Equal(t, http.Header{
+					"Access-Control-Allow-Origin":          {"https://foo.bar.org"},
+					"Access-Control-Allow-Methods":         {"PUT"},
+					"Access-Control-Allow-Headers":         {"Content-Type"},
+					"Access-Control-Allow-Private-Network": {"true"},
+					"Vary": {
+						"Origin, Access-Control-Request-Method, Access-Control-Request-Headers, " +
+							"Access-Control-Request-Private-Network",
+					},
+				}, e.Preflight.Header)
+
+				assert.Equal(t, cors.OutcomeAllowed, e.Actual.Decision.Outcome)
+				assert.Zero(t, e.Actual.Status)
+				assert.Equal(t, http.Header{
+					"Access-Control-Allow-Origin": {"https://foo.bar.org"},
+					"Vary":                        {"Origin"},
+				}, e.Actual.Header)
+			},
+		},
+		{
+			desc: "explains request with not allowed header",
+			req: cors.ExplainRequest{
+				Path:    "/a",
+				Origin:  "https://foo.bar.org",
+				Method:  http.MethodPut,
+				Headers: []string{"x-correlation-id"},
+			},
+			assert: func(t *testing.T, e cors.Explanation) {
+				assert.True(t, e.Found)
+				assert.False(t, e.PreflightOK)
+				assert.Equal(t, cors.ReasonHeaderNotAllowed, e.Preflight.Decision.Reason)
+				assert.Equal(t, http.StatusForbidden, e.Preflight.Status)
+				assert.Equal(t, cors.OutcomeAllowed, e.Actual.Decision.Outcome)
+			},
+		},
+		{
+			desc: "explains request from not allowed origin",
+			req: cors.ExplainRequest{
+				Path:   "/a",
+				Origin: "https://foo.com",
+				Method: http.MethodGet,
+			},
+			assert: func(t *testing.T, e cors.Explanation) {
+				assert.True(t, e.Found)
+				assert.False(t, e.PreflightOK)
+				assert.Equal(t, cors.ReasonOriginNotAllowed, e.Preflight.Decision.Reason)
+				assert.Equal(t, cors.ReasonOriginNotAllowed, e.Actual.Decision.Reason)
+				assert.Equal(t, http.Header{"Vary": {"Origin"}}, e.Actual.Header)
+			},
+		},
+		{
+			desc: "explains request with rule preflight status",
+			req: cors.ExplainRequest{
+				Path:   "/b",
+				Origin: "https://foo.com",
+				Method: http.MethodDelete,
+			},
+			assert: func(t *testing.T, e cors.Explanation) {
+				assert.True(t, e.PreflightOK)
+				assert.Equal(t, http.StatusNoContent, e.Preflight.Status)
+				assert.Equal(t, "*", e.Actual.Header.Get("Access-Control-Allow-Origin"))
+			},
+		},
+		{
+			desc: "explains request to path without rule",
+			req: cors.ExplainRequest{
+				Path:   "/c",
+				Origin: "https://foo.com",
+				Method: http.MethodGet,
+			},
+			assert: func(t *testing.T, e cors.Explanation) {
+				assert.False(t, e.Found)
+				assert.False(t, e.PreflightOK)
+				assert.Equal(t, cors.ReasonNoRule, e.Preflight.Decision.Reason)
+				assert.Equal(t, cors.ReasonNoRule, e.Actual.Decision.Reason)
+				assert.Empty(t, e.Actual.Header)
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tC.assert(t, cors.Explain(rules, tC.req))
+		})
+	}
+}
+
+func TestExplainOptions(t *testing.T) {
+	rules := cors.NewRules("*;*;;*")
+	require.NoError(t, rules.Parse())
+
+	e := cors.Explain(rules, cors.ExplainRequest{
+		Path:   "/a",
+		Origin: "https://foo.com",
+		Method: http.MethodPut,
+	}, cors.PreflightStatus(http.StatusNoContent))
+
+	assert.True(t, e.PreflightOK)
+	assert.Equal(t, http.StatusNoContent, e.Preflight.Status)
+}
+
+func TestExplainDisallowedHandler(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;;PUT")
+	require.NoError(t, rules.Parse())
+
+	in := cors.ExplainRequest{Path: "/a", Origin: "https://bar.foo.org", Method: http.MethodPut}
+	e := cors.Explain(rules, in, cors.DisallowedHandler(http.NotFoundHandler()))
+
+	for _, res := range []cors.Response{e.Preflight, e.Actual} {
+		assert.True(t, res.Handled)
+		assert.Equal(t, http.StatusForbidden, res.Status)
+	}
+
+	out := cors.Compute(rules, cors.Input{Path: in.Path, Method: in.Method, Origin: in.Origin},
+		cors.DisallowedHandler(http.NotFoundHandler()))
+	assert.Equal(t, out.Status, e.Actual.Status)
+}
@@ -0,0 +1,59 @@
+// Package fasthttpcors provides fasthttp middleware applying CORS rules.
+package fasthttpcors
+
+import (
+	"github.com/antklim/cors"
+	"github.com/valyala/fasthttp"
+)
+
+// PathFunc returns the path the rule of the request is resolved by, such as
+// the route template of the request.
+type PathFunc func(ctx *fasthttp.RequestCtx) string
+
+// RequestPath returns the request path.
+func RequestPath(ctx *fasthttp.RequestCtx) string {
+	return string(ctx.Path())
+}
+
+// Middleware returns the middleware applying the rules to requests, the rule
+// is resolved by the path of pathOf, by the request path when pathOf is nil.
+// Preflights and rejected requests are answered without calling the next
+// handler. Requests DisallowedHandler option would handle are rejected with
+// 403.
+func Middleware(rules *cors.Rules, pathOf PathFunc, opts ...cors.Option) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if pathOf == nil {
+		pathOf = RequestPath
+	}
+
+	rh := cors.NewRulesHandler(rules, opts...)
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			h := &ctx.Request.Header
+			out := rh.Compute(cors.Input{
+				Path:                  pathOf(ctx),
+				Method:                string(ctx.Method()),
+				Host:                  string(ctx.Host()),
+				Origin:                string(h.Peek("Origin")),
+				RequestMethod:         string(h.Peek("Access-Control-Request-Method")),
+				RequestHeaders:        string(h.Peek("Access-Control-Request-Headers")),
+				RequestPrivateNetwork: string(h.Peek("Access-Control-Request-Private-Network")) == "true",
+			})
+
+			for _, hh := range out.Headers {
+				ctx.Response.Header.Set(hh.Name, hh.Value)
+			}
+
+			if out.ShortCircuit {
+				ctx.SetStatusCode(out.Status)
+			} else {
+				next(ctx)
+			}
+
+			// the response is sent after the handler returns
+			if len(out.Vary) > 0 {
+				vary := string(ctx.Response.Header.Peek("Vary"))
+				ctx.Response.Header.Set("Vary", cors.MergeVary(vary, out.Vary...))
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package fasthttpcors_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/antklim/cors/fasthttpcors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func serve(t *testing.T, h fasthttp.RequestHandler) *fasthttp.Client {
+	t.Helper()
+
+	ln := fasthttputil.NewInmemoryListener()
+	s := &fasthttp.Server{Handler: h}
+	go s.Serve(ln) // nolint: errcheck
+	t.Cleanup(func() {
+		_ = s.Shutdown()
+	})
+
+	return &fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) {
+			return ln.Dial()
+		},
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	rules := cors.NewRules("/users;https://foo.bar.org;content-type;GET,PUT;expose=X-Request-Id\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+
+	next := func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("Vary", "Accept-Encoding")
+		ctx.Response.Header.Set("X-Request-Id", "1")
+		ctx.SetStatusCode(http.StatusAccepted)
+		ctx.SetBodyString("OK")
+	}
+	c := serve(t, fasthttpcors.Middleware(rules, nil, cors.PreflightStatus(http.StatusNoContent))(next))
+
+	testCases := []struct {
+		desc    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		allow   map[string]string
+		vary    string
+		body    string
+	}{
+		{
+			desc:   "answers preflight",
+			method: http.MethodOptions,
+			path:   "/users",
+			headers: map[string]string{
+				"Origin":                         "https://foo.bar.org",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			status: http.StatusNoContent,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":  "https://foo.bar.org",
+				"Access-Control-Allow-Methods": "PUT",
+				"Access-Control-Allow-Headers": "Content-Type",
+			},
+			vary: "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+		},
+		{
+			desc:   "rejects preflight of method not allowed",
+			method: http.MethodOptions,
+			path:   "/users",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusMethodNotAllowed,
+			allow:  map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:   "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+		},
+		{
+			desc:    "sets headers of actual request",
+			method:  http.MethodPut,
+			path:    "/users",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusAccepted,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":   "https://foo.bar.org",
+				"Access-Control-Expose-Headers": "X-Request-Id",
+			},
+			vary: "Accept-Encoding, Origin",
+			body: "OK",
+		},
+		{
+			desc:    "does not set headers of actual request from disallowed origin",
+			method:  http.MethodPut,
+			path:    "/users",
+			headers: map[string]string{"Origin": "https://bar.foo.org"},
+			status:  http.StatusAccepted,
+			allow:   map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:    "Accept-Encoding, Origin",
+			body:    "OK",
+		},
+		{
+			desc:    "applies wildcard rule to other paths",
+			method:  http.MethodGet,
+			path:    "/projects",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusAccepted,
+			allow:   map[string]string{"Access-Control-Allow-Origin": "*"},
+			vary:    "Accept-Encoding",
+			body:    "OK",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := fasthttp.AcquireRequest()
+			defer fasthttp.ReleaseRequest(req)
+			res := fasthttp.AcquireResponse()
+			defer fasthttp.ReleaseResponse(res)
+
+			req.Header.SetMethod(tC.method)
+			req.SetRequestURI("http://api.example.com" + tC.path)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+			require.NoError(t, c.Do(req, res))
+
+			assert.Equal(t, tC.status, res.StatusCode())
+			for k, v := range tC.allow {
+				assert.Equal(t, v, string(res.Header.Peek(k)), k)
+			}
+			assert.Equal(t, tC.vary, string(res.Header.Peek("Vary")))
+			assert.Equal(t, tC.body, string(res.Body()))
+		})
+	}
+}
+
+func TestMiddlewarePathFunc(t *testing.T) {
+	rules := cors.NewRules("/users/{id};https://foo.bar.org;;PUT")
+	require.NoError(t, rules.Parse())
+
+	pathOf := func(ctx *fasthttp.RequestCtx) string {
+		return "/users/{id}"
+	}
+	next := func(ctx *fasthttp.RequestCtx) {}
+	c := serve(t, fasthttpcors.Middleware(rules, pathOf, cors.RejectDisallowed())(next))
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	res := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(res)
+
+	req.Header.SetMethod(http.MethodPut)
+	req.SetRequestURI("http://api.example.com/users/1")
+	req.Header.Set("Origin", "https://foo.bar.org")
+	require.NoError(t, c.Do(req, res))
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, "https://foo.bar.org", string(res.Header.Peek("Access-Control-Allow-Origin")))
+
+	req.Header.Set("Origin", "https://bar.foo.org")
+	require.NoError(t, c.Do(req, res))
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+}
@@ -0,0 +1,22 @@
+module github.com/antklim/cors/fasthttpcors
+
+go 1.22
+
+require (
+	github.com/antklim/cors v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.10.0
+	github.com/valyala/fasthttp v1.58.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/antklim/cors => ../
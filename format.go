@@ -0,0 +1,182 @@
+package cors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of rules config.
+type Format string
+
+const (
+	FormatTxt  Format = "txt"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// config is the rules config in structured formats: JSON, YAML.
+//
+// Set names are referenced in rules by @name, same as in txt format.
+type config struct {
+	Sets  map[string][]string `json:"sets,omitempty" yaml:"sets,omitempty"`
+	Rules []configRule        `json:"rules" yaml:"rules"`
+}
+
+type configRule struct {
+	Paths                []string `json:"paths" yaml:"paths"`
+	Origins              []string `json:"origins,omitempty" yaml:"origins,omitempty"`
+	Headers              []string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Methods              []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+	ExposedHeaders       []string `json:"exposedHeaders,omitempty" yaml:"exposedHeaders,omitempty"`
+	PrivateNetwork       bool     `json:"privateNetwork,omitempty" yaml:"privateNetwork,omitempty"`
+	PreflightStatus      int      `json:"preflightStatus,omitempty" yaml:"preflightStatus,omitempty"`
+	PreflightPassthrough bool     `json:"preflightPassthrough,omitempty" yaml:"preflightPassthrough,omitempty"`
+}
+
+func (r *Rules) parseStructured(strict bool) error {
+	var c config
+
+	var err error
+	if r.format == FormatJSON {
+		err = json.Unmarshal([]byte(r.raw), &c)
+	} else {
+		err = yaml.Unmarshal([]byte(r.raw), &c)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", parseErr, err)
+	}
+
+	s := newSets()
+	names := make([]string, 0, len(c.Sets))
+	for name, values := range c.Sets {
+		name = strings.TrimPrefix(strings.TrimSpace(name), setMark)
+		if name == "" {
+			return fmt.Errorf("%s: set name cannot be empty", parseErr)
+		}
+
+		if err := validateValues(values, "set "+setMark+name); err != nil {
+			return err
+		}
+
+		s.raw[name] = line{s: strings.Join(values, valuesDlm)}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := s.resolveAll(names); err != nil {
+		return err
+	}
+
+	lines := make([]line, 0, len(c.Rules))
+	for i, cr := range c.Rules {
+		l, err := cr.line(i + 1)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, l)
+	}
+
+	return r.parseRules(s, lines, strict)
+}
+
+// line returns the rule in txt format.
+func (cr configRule) line(num int) (line, error) {
+	where := fmt.Sprintf("rule %d", num)
+	for _, values := range [][]string{cr.Paths, cr.Origins, cr.Headers, cr.Methods, cr.ExposedHeaders} {
+		if err := validateValues(values, where); err != nil {
+			return line{}, err
+		}
+	}
+
+	var opts []string
+	if cr.PrivateNetwork {
+		opts = append(opts, optPrivateNetwork)
+	}
+	if cr.PreflightPassthrough {
+		opts = append(opts, optPassthrough)
+	}
+	if cr.PreflightStatus != 0 {
+		opts = append(opts, optStatus+optValueDlm+strconv.Itoa(cr.PreflightStatus))
+	}
+	for _, h := range cr.ExposedHeaders {
+		opts = append(opts, optExpose+optValueDlm+h)
+	}
+
+	fields := make([]string, fNum)
+	fields[pIdx] = strings.Join(cr.Paths, valuesDlm)
+	fields[oIdx] = strings.Join(cr.Origins, valuesDlm)
+	fields[hIdx] = strings.Join(cr.Headers, valuesDlm)
+	fields[mIdx] = strings.Join(cr.Methods, valuesDlm)
+	fields[xIdx] = strings.Join(opts, valuesDlm)
+
+	return line{num: num, s: strings.Join(fields, fieldsDlm)}, nil
+}
+
+// validateValues checks that the values of structured config do not contain
+// txt format delimiters.
+func validateValues(values []string, where string) error {
+	for _, v := range values {
+		if strings.Contains(v, fieldsDlm) || strings.Contains(v, valuesDlm) {
+			return fmt.Errorf("%s: invalid value %q in %s", parseErr, v, where)
+		}
+	}
+	return nil
+}
+
+// Encode returns the config of parsed rules in the format. Sets are expanded,
+// comments are dropped and each path has its own rule. Rules with values
+// containing txt delimiters, built by RulesBuilder, cannot be encoded in txt.
+func (r *Rules) Encode(f Format) (string, error) {
+	c := config{Rules: make([]configRule, 0, len(r.op))}
+	for _, p := range r.op {
+		rule := r.pr[p]
+		c.Rules = append(c.Rules, configRule{
+			Paths:                []string{p},
+			Origins:              rule.o,
+			Headers:              rule.h,
+			Methods:              rule.m,
+			ExposedHeaders:       rule.e,
+			PrivateNetwork:       rule.pn,
+			PreflightStatus:      rule.status,
+			PreflightPassthrough: rule.pt,
+		})
+	}
+
+	switch f {
+	case FormatTxt, "":
+		return c.txt()
+	case FormatJSON:
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + rulesDlm, nil
+	case FormatYAML:
+		b, err := yaml.Marshal(c)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("unsupported format %s", f)
+	}
+}
+
+// txt returns the config in txt format.
+func (c config) txt() (string, error) {
+	var sb strings.Builder
+	for i, cr := range c.Rules {
+		l, err := cr.line(i + 1)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(strings.TrimSuffix(l.s, fieldsDlm))
+		sb.WriteString(rulesDlm)
+	}
+	return sb.String(), nil
+}
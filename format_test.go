@@ -0,0 +1,204 @@
+package cors_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const txtConfig = `@frontends = https://a.com,https://b.com
+/a,/b;@frontends;content-type;PUT,GET;private-network,status=204,expose=X-Request-Id
+*;*;;*;passthrough`
+
+const jsonConfig = `{
+  "sets": {"frontends": ["https://a.com", "https://b.com"]},
+  "rules": [
+    {
+      "paths": ["/a", "/b"],
+      "origins": ["@frontends"],
+      "headers": ["content-type"],
+      "methods": ["PUT", "GET"],
+      "exposedHeaders": ["X-Request-Id"],
+      "privateNetwork": true,
+      "preflightStatus": 204
+    },
+    {
+      "paths": ["*"],
+      "origins": ["*"],
+      "methods": ["*"],
+      "preflightPassthrough": true
+    }
+  ]
+}`
+
+const yamlConfig = `
+sets:
+  frontends: [https://a.com, https://b.com]
+rules:
+  - paths: [/a, /b]
+    origins: ["@frontends"]
+    headers: [content-type]
+    methods: [PUT, GET]
+    exposedHeaders: [X-Request-Id]
+    privateNetwork: true
+    preflightStatus: 204
+  - paths: ["*"]
+    origins: ["*"]
+    methods: ["*"]
+    preflightPassthrough: true
+`
+
+func TestRulesFormats(t *testing.T) {
+	txt := cors.NewRules(txtConfig)
+	require.NoError(t, txt.Parse())
+
+	testCases := []struct {
+		desc   string
+		config string
+		format cors.Format
+	}{
+		{
+			desc:   "parses txt config",
+			config: txtConfig,
+			format: cors.FormatTxt,
+		},
+		{
+			desc:   "parses json config",
+			config: jsonConfig,
+			format: cors.FormatJSON,
+		},
+		{
+			desc:   "parses yaml config",
+			config: yamlConfig,
+			format: cors.FormatYAML,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rules := cors.NewRulesWithFormat(tC.config, tC.format)
+			require.NoError(t, rules.Parse())
+			assert.Equal(t, []string{"/a", "/b", "*"}, rules.Paths())
+
+			for _, p := range rules.Paths() {
+				want, _ := txt.OfPath(p)
+				got, ok := rules.OfPath(p)
+				assert.True(t, ok)
+				assert.Equal(t, want, got)
+			}
+
+			rule, _ := rules.OfPath("/a")
+			assert.Equal(t, []string{"https://a.com", "https://b.com"}, rule.Origins())
+			assert.Equal(t, []string{http.MethodPut, http.MethodGet}, rule.Methods())
+			assert.True(t, rule.AllowPrivateNetwork())
+			assert.Equal(t, http.StatusNoContent, rule.PreflightStatus())
+		})
+	}
+}
+
+func TestRulesFormatsParseError(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config string
+		format cors.Format
+		err    string
+	}{
+		{
+			desc:   "fails when format is not supported",
+			config: "*;;;",
+			format: cors.Format("toml"),
+			err:    "invalid cors rules: unsupported format toml",
+		},
+		{
+			desc:   "fails when json is invalid",
+			config: "{",
+			format: cors.FormatJSON,
+			err:    "invalid cors rules: unexpected end of JSON input",
+		},
+		{
+			desc:   "fails when json has no rules",
+			config: `{"rules": []}`,
+			format: cors.FormatJSON,
+			err:    "invalid cors rules: cannot be empty",
+		},
+		{
+			desc:   "fails when json rule has invalid method",
+			config: `{"rules": [{"paths": ["/a"]}, {"paths": ["/b"], "methods": ["foo"]}]}`,
+			format: cors.FormatJSON,
+			err:    "invalid cors rules: invalid HTTP method FOO in rule 2",
+		},
+		{
+			desc:   "fails when json rule has value with delimiter",
+			config: `{"rules": [{"paths": ["/a;/b"]}]}`,
+			format: cors.FormatJSON,
+			err:    `invalid cors rules: invalid value "/a;/b" in rule 1`,
+		},
+		{
+			desc:   "fails when json rule references undefined set",
+			config: `{"rules": [{"paths": ["/a"], "origins": ["@foo"]}]}`,
+			format: cors.FormatJSON,
+			err:    "invalid cors rules: undefined set @foo in rule 1",
+		},
+		{
+			desc:   "fails when yaml sets have cyclic references",
+			config: "sets:\n  foo: ['@bar']\n  bar: ['@foo']\nrules:\n  - paths: [/a]",
+			format: cors.FormatYAML,
+			err:    "invalid cors rules: cyclic reference to set @bar",
+		},
+		{
+			desc:   "fails when yaml rule has invalid preflight status",
+			config: "rules:\n  - paths: [/a]\n    preflightStatus: 302",
+			format: cors.FormatYAML,
+			err:    "invalid cors rules: invalid preflight status 302 in rule 1",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rules := cors.NewRulesWithFormat(tC.config, tC.format)
+			assert.EqualError(t, rules.Parse(), tC.err)
+		})
+	}
+}
+
+func TestRulesEncode(t *testing.T) {
+	rules := cors.NewRules(txtConfig)
+	require.NoError(t, rules.Parse())
+
+	txt, err := rules.Encode(cors.FormatTxt)
+	require.NoError(t, err)
+	assert.Equal(t, `/a;https://a.com,https://b.com;content-type;PUT,GET;private-network,status=204,expose=X-Request-Id
+/b;https://a.com,https://b.com;content-type;PUT,GET;private-network,status=204,expose=X-Request-Id
+*;*;;DELETE,GET,HEAD,PATCH,POST,PUT;passthrough
+`, txt)
+
+	for _, f := range []cors.Format{cors.FormatTxt, cors.FormatJSON, cors.FormatYAML} {
+		config, err := rules.Encode(f)
+		require.NoError(t, err)
+
+		decoded := cors.NewRulesWithFormat(config, f)
+		require.NoError(t, decoded.Parse(), f)
+		assert.Equal(t, rules.Paths(), decoded.Paths(), f)
+		for _, p := range rules.Paths() {
+			want, _ := rules.OfPath(p)
+			got, _ := decoded.OfPath(p)
+			assert.Equal(t, want, got, f)
+		}
+	}
+
+	_, err = rules.Encode(cors.Format("toml"))
+	assert.EqualError(t, err, "unsupported format toml")
+}
+
+func TestRulesEncodeBuiltRulesWithDelimiters(t *testing.T) {
+	rules := cors.NewRulesBuilder().
+		Add(cors.NewRuleBuilder().WithOrigins("https://a.com;https://b.com").Build(), "/a").
+		Build()
+
+	_, err := rules.Encode(cors.FormatTxt)
+	assert.EqualError(t, err, `invalid cors rules: invalid value "https://a.com;https://b.com" in rule 1`)
+
+	_, err = rules.Encode(cors.FormatJSON)
+	assert.NoError(t, err)
+}
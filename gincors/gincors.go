@@ -0,0 +1,87 @@
+// Package gincors provides Gin middleware applying CORS rules.
+package gincors
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/antklim/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns the middleware applying the rules of route paths to
+// requests. Gin does not route preflights of paths without OPTIONS routes,
+// use OptionsRoutes to add them. Requests without routes are passed as is.
+func Middleware(rules *cors.Rules, opts ...cors.Option) gin.HandlerFunc {
+	rh := cors.NewRulesHandler(rules, opts...)
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			c.Next()
+			return
+		}
+
+		called := false
+		w := c.Writer
+		rh.Serve(path, w, c.Request, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			called = true
+			c.Request = r
+			c.Writer = &responseWriter{ResponseWriter: w, w: rw}
+			c.Next()
+			c.Writer = w
+		}))
+
+		if !called {
+			c.Abort()
+		}
+	}
+}
+
+// OptionsRoutes adds OPTIONS routes of the paths, so Gin routes preflights to
+// Middleware. Paths without rules are not added.
+func OptionsRoutes(r gin.IRoutes, paths []string, rules *cors.Rules) error {
+	if len(paths) == 0 {
+		return errors.New("invalid paths list: cannot be empty")
+	}
+
+	added := make(map[string]bool)
+	for _, p := range paths {
+		if _, ok := rules.OfPath(p); ok && !added[p] {
+			added[p] = true
+			r.OPTIONS(p, func(*gin.Context) {})
+		}
+	}
+	return nil
+}
+
+// responseWriter writes the response of the next handlers through the CORS
+// handler writer, it merges CORS values into the Vary header.
+type responseWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.w.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	return rw.w.Write(b)
+}
+
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	return rw.w.Write([]byte(s))
+}
+
+func (rw *responseWriter) WriteHeaderNow() {
+	if !rw.Written() {
+		rw.w.WriteHeader(rw.Status())
+	}
+	rw.ResponseWriter.WriteHeaderNow()
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,124 @@
+package cors
+
+import (
+	"net/http"
+	"strings"
+)
+
+// gRPC-Web and Connect protocol headers.
+const (
+	HeaderXGrpcWeb               = "X-Grpc-Web"
+	HeaderXUserAgent             = "X-User-Agent"
+	HeaderGrpcTimeout            = "Grpc-Timeout"
+	HeaderGrpcStatus             = "Grpc-Status"
+	HeaderGrpcMessage            = "Grpc-Message"
+	HeaderGrpcStatusDetailsBin   = "Grpc-Status-Details-Bin"
+	HeaderConnectProtocolVersion = "Connect-Protocol-Version"
+	HeaderConnectTimeoutMs       = "Connect-Timeout-Ms"
+	HeaderConnectContentEncoding = "Connect-Content-Encoding"
+	HeaderConnectAcceptEncoding  = "Connect-Accept-Encoding"
+	headerContentType            = "Content-Type"
+	headerContentEncoding        = "Content-Encoding"
+)
+
+// GRPCWebHeaders returns the request headers sent by gRPC-Web clients.
+func GRPCWebHeaders() []string {
+	return []string{headerContentType, HeaderXGrpcWeb, HeaderXUserAgent, HeaderGrpcTimeout}
+}
+
+// GRPCWebExposedHeaders returns the response headers read by gRPC-Web
+// clients. Unary responses carry the status in the headers.
+func GRPCWebExposedHeaders() []string {
+	return []string{HeaderGrpcStatus, HeaderGrpcMessage, HeaderGrpcStatusDetailsBin}
+}
+
+// ConnectHeaders returns the request headers sent by Connect clients, they
+// include gRPC-Web headers since Connect clients speak both protocols.
+func ConnectHeaders() []string {
+	return append(GRPCWebHeaders(),
+		HeaderConnectProtocolVersion,
+		HeaderConnectTimeoutMs,
+		HeaderConnectContentEncoding,
+		HeaderConnectAcceptEncoding,
+		headerContentEncoding,
+	)
+}
+
+// ConnectExposedHeaders returns the response headers read by Connect
+// clients.
+func ConnectExposedHeaders() []string {
+	return append(GRPCWebExposedHeaders(), HeaderConnectContentEncoding, headerContentEncoding)
+}
+
+// WithGRPCWeb adds the headers and POST method of gRPC-Web calls to the rule,
+// values set before are kept.
+func (b RuleBuilder) WithGRPCWeb() RuleBuilder {
+	return b.
+		WithHeaders(appendMissing(b.expr[ruleHeaders], GRPCWebHeaders())...).
+		WithExposedHeaders(appendMissing(b.expr[ruleExposed], GRPCWebExposedHeaders())...).
+		WithMethods(appendMissing(b.rm, []string{http.MethodPost})...)
+}
+
+// WithConnect adds the headers and methods of Connect and gRPC-Web calls to
+// the rule, values set before are kept. Connect clients send side-effect free
+// unary calls with GET.
+func (b RuleBuilder) WithConnect() RuleBuilder {
+	return b.
+		WithHeaders(appendMissing(b.expr[ruleHeaders], ConnectHeaders())...).
+		WithExposedHeaders(appendMissing(b.expr[ruleExposed], ConnectExposedHeaders())...).
+		WithMethods(appendMissing(b.rm, []string{http.MethodGet, http.MethodPost})...)
+}
+
+// appendMissing appends to a copy of l the values missing in it, header
+// names are compared case-insensitively.
+func appendMissing(l, values []string) []string {
+	res := append([]string{}, l...)
+	for _, v := range values {
+		found := false
+		for _, x := range res {
+			if strings.EqualFold(x, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// GRPCPath returns the path of gRPC method calls: /package.Service/Method.
+func GRPCPath(service, method string) string {
+	return "/" + service + "/" + method
+}
+
+// GRPCPaths returns the paths of the service methods calls.
+func GRPCPaths(service string, methods ...string) []string {
+	paths := make([]string, 0, len(methods))
+	for _, m := range methods {
+		paths = append(paths, GRPCPath(service, m))
+	}
+	return paths
+}
+
+// ParseGRPCPath returns the service and method of gRPC method call path. It
+// reports whether the path is /package.Service/Method.
+func ParseGRPCPath(path string) (service, method string, ok bool) {
+	if !strings.HasPrefix(path, "/") {
+		return "", "", false
+	}
+
+	service, method, ok = strings.Cut(path[1:], "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", false
+	}
+
+	return service, method, true
+}
+
+// GRPCServicePattern returns the http.ServeMux pattern of the service
+// methods calls: /package.Service/.
+func GRPCServicePattern(service string) string {
+	return "/" + service + "/"
+}
@@ -0,0 +1,87 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleBuilderGRPCPresets(t *testing.T) {
+	rule := cors.NewRuleBuilder().
+		WithHeaders("x-grpc-web", "Authorization").
+		WithExposedHeaders("X-Request-Id").
+		WithGRPCWeb().
+		Build()
+	assert.Equal(t, []string{"x-grpc-web", "Authorization", "Content-Type", "X-User-Agent", "Grpc-Timeout"}, rule.Headers())
+	assert.Equal(t, []string{"X-Request-Id", "Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}, rule.ExposedHeaders())
+	assert.Equal(t, []string{http.MethodPost}, rule.Methods())
+
+	rule = cors.NewRuleBuilder().WithMethods(http.MethodPut).WithConnect().Build()
+	assert.Equal(t, cors.ConnectHeaders(), rule.Headers())
+	assert.Equal(t, cors.ConnectExposedHeaders(), rule.ExposedHeaders())
+	assert.Equal(t, []string{http.MethodPut, http.MethodGet, http.MethodPost}, rule.Methods())
+
+	_, err := cors.NewRuleBuilder().WithOrigins("https://foo.bar.org").WithConnect().BuildE()
+	assert.NoError(t, err)
+}
+
+func TestGRPCWebRequests(t *testing.T) {
+	rule := cors.NewRuleBuilder().WithOrigins("https://foo.bar.org").WithGRPCWeb().Build()
+	path := cors.GRPCPath("acme.user.v1.UserService", "GetUser")
+	h := cors.Middleware(path, rule)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Grpc-Status", "0")
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", "https://foo.bar.org")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,x-user-agent,grpc-timeout")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Content-Type,X-Grpc-Web,X-User-Agent,Grpc-Timeout", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Expose-Headers"))
+
+	req = httptest.NewRequest(http.MethodPost, path, nil)
+	req.Header.Set("Origin", "https://foo.bar.org")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, "https://foo.bar.org", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin", rr.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, "0", rr.Header().Get("Grpc-Status"))
+}
+
+func TestGRPCPaths(t *testing.T) {
+	assert.Equal(t, "/acme.v1.Greeter/SayHello", cors.GRPCPath("acme.v1.Greeter", "SayHello"))
+	assert.Equal(t, []string{"/acme.v1.Greeter/SayHello", "/acme.v1.Greeter/SayBye"},
+		cors.GRPCPaths("acme.v1.Greeter", "SayHello", "SayBye"))
+	assert.Equal(t, "/acme.v1.Greeter/", cors.GRPCServicePattern("acme.v1.Greeter"))
+
+	testCases := []struct {
+		path    string
+		service string
+		method  string
+		ok      bool
+	}{
+		{path: "/acme.v1.Greeter/SayHello", service: "acme.v1.Greeter", method: "SayHello", ok: true},
+		{path: "/Greeter/SayHello", service: "Greeter", method: "SayHello", ok: true},
+		{path: "acme.v1.Greeter/SayHello"},
+		{path: "/acme.v1.Greeter/"},
+		{path: "/acme.v1.Greeter"},
+		{path: "//SayHello"},
+		{path: "/acme.v1.Greeter/SayHello/1"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.path, func(t *testing.T) {
+			service, method, ok := cors.ParseGRPCPath(tC.path)
+			assert.Equal(t, tC.ok, ok)
+			assert.Equal(t, tC.service, service)
+			assert.Equal(t, tC.method, method)
+		})
+	}
+}
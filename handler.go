@@ -0,0 +1,331 @@
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	originHeader                = "Origin"
+	varyHeader                  = "Vary"
+	allowOriginHeader           = "Access-Control-Allow-Origin"
+	allowMethodsHeader          = "Access-Control-Allow-Methods"
+	allowHeadersHeader          = "Access-Control-Allow-Headers"
+	allowPrivateNetworkHeader   = "Access-Control-Allow-Private-Network"
+	exposeHeadersHeader         = "Access-Control-Expose-Headers"
+	requestMethodHeader         = "Access-Control-Request-Method"
+	requestHeadersHeader        = "Access-Control-Request-Headers"
+	requestPrivateNetworkHeader = "Access-Control-Request-Private-Network"
+)
+
+// Simple methods and headers, they don't have to be listed in preflight responses.
+var (
+	defaultMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	defaultHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Origin"}
+)
+
+// handler applies CORS rule to the requests.
+type handler struct {
+	path    string
+	rp      string // path of the rule
+	rule    Rule
+	origins []string
+	headers []string // canonical allowed headers including default ones
+	methods []string
+	exposed []string // canonical exposed headers
+	pn      bool
+	status  int
+	pt      bool
+	next    http.Handler
+	dp      disallowedPolicy
+	dh      http.Handler
+	obs     []Observer
+	ro      *reportOnly
+}
+
+func newHandler(path string, rule Rule, next http.Handler, opts options) *handler {
+	h := &handler{
+		path:    path,
+		rp:      path,
+		rule:    rule,
+		headers: append([]string{}, defaultHeaders...),
+		pn:      rule.pn,
+		status:  http.StatusOK,
+		pt:      rule.pt || opts.pt,
+		next:    next,
+		dp:      opts.dp,
+		dh:      opts.dh,
+		obs:     opts.obs,
+	}
+
+	if opts.ro != nil {
+		h.ro = newReportOnly(path, opts)
+	}
+
+	if rule.status != 0 {
+		h.status = rule.status
+	} else if opts.status != 0 {
+		h.status = opts.status
+	}
+
+	for _, o := range rule.o {
+		if o == wildcard {
+			h.origins = []string{wildcard}
+			break
+		}
+		h.origins = append(h.origins, o)
+	}
+
+	h.headers = appendCanonical(h.headers, rule.h, http.CanonicalHeaderKey)
+	h.exposed = appendCanonical(h.exposed, rule.e, http.CanonicalHeaderKey)
+	h.methods = appendCanonical(h.methods, rule.m, strings.ToUpper)
+
+	return h
+}
+
+// appendCanonical appends canonical forms of values missing in dst, empty
+// values are skipped.
+func appendCanonical(dst, values []string, canonical func(string) string) []string {
+	for _, v := range values {
+		if cv := canonical(strings.TrimSpace(v)); cv != "" && !contains(dst, cv) {
+			dst = append(dst, cv)
+		}
+	}
+	return dst
+}
+
+// result is the result of applying the rule to the request.
+type result struct {
+	d      Decision
+	header http.Header // CORS response headers
+	vary   []string    // values of Vary header
+	status int         // status of the response written by the handler, 0 - the request is passed on
+	handle bool        // the request is passed to the disallowed handler
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	in := inputOf(h.path, r)
+	res := h.evaluate(in)
+	res.d.Duration = time.Since(start)
+	defer h.observe(res.d)
+
+	if h.ro != nil {
+		h.ro.compare(res.d, in)
+	}
+
+	out := res.output()
+	vw := &varyWriter{ResponseWriter: rw, vary: out.Vary}
+	defer vw.merge() // when the next handler writes nothing
+
+	w := vw.writer()
+	for _, hh := range out.Headers {
+		w.Header().Set(hh.Name, hh.Value)
+	}
+
+	switch {
+	case res.handle:
+		h.dh.ServeHTTP(w, r)
+	case out.ShortCircuit:
+		w.WriteHeader(out.Status)
+	default:
+		h.next.ServeHTTP(w, r)
+	}
+}
+
+// evaluate applies the rule to the request without serving it.
+func (h *handler) evaluate(in Input) result {
+	res := result{
+		d: Decision{
+			Path:      h.path,
+			RulePath:  h.rp,
+			Rule:      h.rule,
+			Preflight: h.isPreflight(in),
+			Origin:    in.Origin,
+			Method:    in.Method,
+			Outcome:   OutcomeAllowed,
+		},
+		header: make(http.Header),
+		vary:   h.varyOf(in),
+	}
+
+	if !h.isOriginAllowed(res.d.Origin) {
+		switch {
+		case res.d.Origin == "":
+			res.d.skip(ReasonNoOrigin)
+		case isSameOrigin(res.d.Origin, in.Host):
+			res.d.skip(ReasonSameOrigin)
+		default:
+			res.d.deny(ReasonOriginNotAllowed)
+		}
+		h.disallowed(&res)
+		return res
+	}
+
+	if res.d.Preflight {
+		if ok := h.preflight(in, &res); !ok {
+			return res
+		}
+	}
+
+	origin := res.d.Origin
+	if h.anyOrigin() {
+		origin = wildcard
+	}
+	res.header.Set(allowOriginHeader, origin)
+
+	if !res.d.Preflight && len(h.exposed) > 0 {
+		res.header.Set(exposeHeadersHeader, strings.Join(h.exposed, ", "))
+	}
+
+	if res.d.Preflight && !h.pt {
+		res.status = h.status
+	}
+
+	return res
+}
+
+// varyOf returns the request headers the response depends on. Responses
+// depend on the origin when specific origins are allowed. Responses to OPTIONS
+// requests depend on preflight headers whatever the outcome is, so caches do
+// not serve responses without CORS headers to preflights.
+func (h *handler) varyOf(in Input) []string {
+	if in.Method != http.MethodOptions {
+		if h.anyOrigin() {
+			return nil
+		}
+		return []string{originHeader}
+	}
+
+	vary := []string{originHeader, requestMethodHeader, requestHeadersHeader}
+	if h.pn {
+		vary = append(vary, requestPrivateNetworkHeader)
+	}
+	return vary
+}
+
+// preflight validates preflight request and sets preflight response headers.
+// It returns false when the request is rejected.
+func (h *handler) preflight(in Input, res *result) bool {
+	d := &res.d
+	if in.RequestMethod == "" {
+		d.Method = ""
+		d.deny(ReasonNoRequestMethod)
+		res.status = http.StatusBadRequest
+		return false
+	}
+
+	d.Method = in.RequestMethod
+	d.Headers = requestedHeaders(in.RequestHeaders)
+
+	if !contains(h.methods, d.Method) {
+		d.deny(ReasonMethodNotAllowed)
+		res.status = http.StatusMethodNotAllowed
+		return false
+	}
+
+	var headers []string
+	for _, ch := range d.Headers {
+		if contains(defaultHeaders, ch) {
+			continue
+		}
+
+		if !contains(h.headers, ch) {
+			d.deny(ReasonHeaderNotAllowed)
+			res.status = http.StatusForbidden
+			return false
+		}
+
+		headers = append(headers, ch)
+	}
+
+	if len(headers) > 0 {
+		res.header.Set(allowHeadersHeader, strings.Join(headers, valuesDlm))
+	}
+
+	if !contains(defaultMethods, d.Method) {
+		res.header.Set(allowMethodsHeader, d.Method)
+	}
+
+	if h.pn && in.RequestPrivateNetwork {
+		res.header.Set(allowPrivateNetworkHeader, "true")
+	}
+
+	return true
+}
+
+// disallowed decides how the request without allowed origin is handled
+// according to the disallowed policy. Preflights are passed on only when
+// preflight passthrough is set.
+func (h *handler) disallowed(res *result) {
+	if res.d.Outcome == OutcomeDenied {
+		switch h.dp {
+		case disallowedReject:
+			res.status = http.StatusForbidden
+			return
+		case disallowedHandle:
+			res.handle = true
+			return
+		case disallowedPass:
+		}
+	}
+
+	if res.d.Preflight && !h.pt {
+		res.status = http.StatusOK
+	}
+}
+
+// isPreflight reports whether the request is a preflight. With preflight
+// passthrough OPTIONS requests without requested method are actual requests
+// of the next handler.
+func (h *handler) isPreflight(in Input) bool {
+	if in.Method != http.MethodOptions {
+		return false
+	}
+	return !h.pt || in.RequestMethod != ""
+}
+
+func (h *handler) observe(d Decision) {
+	for _, o := range h.obs {
+		o.Observe(d)
+	}
+}
+
+func (h *handler) isOriginAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	if h.anyOrigin() {
+		return true
+	}
+
+	return contains(h.origins, origin)
+}
+
+// anyOrigin reports whether all origins are allowed.
+func (h *handler) anyOrigin() bool {
+	return len(h.origins) == 0 || h.origins[0] == wildcard
+}
+
+// isSameOrigin reports whether the origin host is the request host.
+func isSameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host != "" && u.Host == host
+}
+
+// requestedHeaders returns canonical headers of the preflight request.
+func requestedHeaders(s string) []string {
+	var headers []string
+	for _, v := range strings.Split(s, valuesDlm) {
+		if ch := http.CanonicalHeaderKey(strings.TrimSpace(v)); ch != "" {
+			headers = append(headers, ch)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,100 @@
+package cors
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HostRules are the rules of virtual hosts. Paths of a host are resolved
+// within the host rules only, the default rules apply to other hosts.
+//
+// HostMiddleware applies the rules to net/http handlers. OptionsRoutes,
+// Attach, RegisterOptionsRoutes and framework adapters take the rules of one
+// host: use Rules of the host with routers matching the host, such as gorilla
+// mux subrouters with Host matchers.
+type HostRules struct {
+	hosts map[string]*Rules
+	def   *Rules
+}
+
+// NewHostRules creates the rules of the hosts with the default rules, def can
+// be nil. Rules must be parsed. Host names are case-insensitive, hosts can
+// have ports.
+func NewHostRules(def *Rules, hosts map[string]*Rules) *HostRules {
+	hr := &HostRules{hosts: make(map[string]*Rules, len(hosts)), def: def}
+	for h, r := range hosts {
+		hr.hosts[strings.ToLower(h)] = r
+	}
+	return hr
+}
+
+// Hosts returns sorted hosts having own rules.
+func (hr *HostRules) Hosts() []string {
+	hosts := make([]string, 0, len(hr.hosts))
+	for h := range hr.hosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Rules returns the rules of the request host. The host with port matches
+// rules of the host with the same port first and rules of the host without
+// port otherwise. It returns the default rules when the host does not match,
+// nil when there are no default rules.
+func (hr *HostRules) Rules(host string) *Rules {
+	host = strings.ToLower(host)
+	if r, ok := hr.hosts[host]; ok {
+		return r
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		if r, ok := hr.hosts[h]; ok {
+			return r
+		}
+	}
+
+	return hr.def
+}
+
+// OfPath returns the rule of the path within the rules of the host.
+func (hr *HostRules) OfPath(host, path string) (Rule, bool) {
+	r := hr.Rules(host)
+	if r == nil {
+		return Rule{}, false
+	}
+	return r.OfPath(path)
+}
+
+// HostMiddleware returns the middleware applying the rules of the request host
+// to requests, the rule is resolved by the path of pathOf, by the request path
+// when pathOf is nil. RoutePath resolves rules by gorilla mux route templates.
+// Requests without rules are passed to the next handler as is.
+func HostMiddleware(hr *HostRules, pathOf PathFunc, opts ...Option) func(http.Handler) http.Handler {
+	if pathOf == nil {
+		pathOf = RequestPath
+	}
+
+	handlers := make(map[*Rules]*RulesHandler, len(hr.hosts)+1)
+	for _, r := range hr.hosts {
+		if _, ok := handlers[r]; !ok {
+			handlers[r] = NewRulesHandler(r, opts...)
+		}
+	}
+	if _, ok := handlers[hr.def]; hr.def != nil && !ok {
+		handlers[hr.def] = NewRulesHandler(hr.def, opts...)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rules := hr.Rules(r.Host)
+			if rules == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			handlers[rules].Serve(pathOf(r), w, r, next)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package cors_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHostRules(t *testing.T, withDefault bool) *cors.HostRules {
+	t.Helper()
+
+	parse := func(config string) *cors.Rules {
+		r := cors.NewRules(config)
+		require.NoError(t, r.Parse())
+		return r
+	}
+
+	var def *cors.Rules
+	if withDefault {
+		def = parse("*;*;;GET")
+	}
+
+	return cors.NewHostRules(def, map[string]*cors.Rules{
+		"API.example.com":        parse("/users;https://app.example.com;;GET,PUT"),
+		"admin.example.com":      parse("/users;https://admin.example.com;;GET,PUT,DELETE\n*;https://admin.example.com;;GET"),
+		"admin.example.com:8443": parse("/users;https://admin.example.com:8443;;GET"),
+	})
+}
+
+func TestHostRulesOfPath(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		withDefault bool
+		host        string
+		path        string
+		found       bool
+		origins     []string
+	}{
+		{
+			desc:    "resolves path within host rules",
+			host:    "api.example.com",
+			path:    "/users",
+			found:   true,
+			origins: []string{"https://app.example.com"},
+		},
+		{
+			desc:    "ignores port of host without own rules",
+			host:    "api.example.com:8080",
+			path:    "/users",
+			found:   true,
+			origins: []string{"https://app.example.com"},
+		},
+		{
+			desc:    "resolves path within rules of host with port",
+			host:    "ADMIN.example.com:8443",
+			path:    "/users",
+			found:   true,
+			origins: []string{"https://admin.example.com:8443"},
+		},
+		{
+			desc:    "resolves path to host wildcard rule",
+			host:    "admin.example.com",
+			path:    "/projects",
+			found:   true,
+			origins: []string{"https://admin.example.com"},
+		},
+		{
+			desc:        "does not fall back to default rules when host matches",
+			withDefault: true,
+			host:        "api.example.com",
+			path:        "/projects",
+		},
+		{
+			desc:        "falls back to default rules when host does not match",
+			withDefault: true,
+			host:        "www.example.com",
+			path:        "/users",
+			found:       true,
+			origins:     []string{"*"},
+		},
+		{
+			desc: "not found when host does not match without default rules",
+			host: "www.example.com",
+			path: "/users",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rule, found := newHostRules(t, tC.withDefault).OfPath(tC.host, tC.path)
+			assert.Equal(t, tC.found, found)
+			assert.Equal(t, tC.origins, rule.Origins())
+		})
+	}
+}
+
+func TestHostRulesHosts(t *testing.T) {
+	hr := newHostRules(t, false)
+	assert.Equal(t, []string{"admin.example.com", "admin.example.com:8443", "api.example.com"}, hr.Hosts())
+	assert.Nil(t, hr.Rules("www.example.com"))
+}
+
+func TestHostMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	})
+	h := cors.HostMiddleware(newHostRules(t, true), nil, cors.PreflightStatus(http.StatusNoContent))(next)
+
+	testCases := []struct {
+		desc    string
+		method  string
+		url     string
+		headers map[string]string
+		status  int
+		origin  string
+		body    string
+	}{
+		{
+			desc:   "answers preflight with host rule",
+			method: http.MethodOptions,
+			url:    "https://admin.example.com/users",
+			headers: map[string]string{
+				"Origin":                        "https://admin.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusNoContent,
+			origin: "https://admin.example.com",
+		},
+		{
+			desc:   "rejects preflight of method not allowed by host rule",
+			method: http.MethodOptions,
+			url:    "https://api.example.com/users",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:    "does not allow origin of other host rule",
+			method:  http.MethodGet,
+			url:     "https://api.example.com/users",
+			headers: map[string]string{"Origin": "https://admin.example.com"},
+			status:  http.StatusOK,
+			body:    "OK",
+		},
+		{
+			desc:    "passes request to path without host rule",
+			method:  http.MethodGet,
+			url:     "https://api.example.com/projects",
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			status:  http.StatusOK,
+			body:    "OK",
+		},
+		{
+			desc:    "applies default rules to other hosts",
+			method:  http.MethodGet,
+			url:     "https://www.example.com/users",
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			status:  http.StatusOK,
+			origin:  "*",
+			body:    "OK",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tC.method, tC.url, nil)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tC.status, rr.Code)
+			assert.Equal(t, tC.origin, rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tC.body, rr.Body.String())
+		})
+	}
+}
+
+func TestHostMiddlewareRoutePath(t *testing.T) {
+	rules := cors.NewRules("/users/{id};https://app.example.com;;GET,DELETE")
+	require.NoError(t, rules.Parse())
+	hr := cors.NewHostRules(nil, map[string]*cors.Rules{"api.example.com": rules})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	})
+	router.Use(cors.HostMiddleware(hr, cors.RoutePath))
+
+	req := httptest.NewRequest(http.MethodOptions, "https://api.example.com/users/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "DELETE", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestHostRulesAttach(t *testing.T) {
+	hr := newHostRules(t, false)
+
+	router := mux.NewRouter()
+	for _, host := range []string{"api.example.com", "admin.example.com"} {
+		sub := router.Host(host).Subrouter()
+		sub.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "OK")
+		}).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
+		require.NoError(t, cors.Attach(sub, hr.Rules(host)))
+	}
+
+	testCases := []struct {
+		desc   string
+		url    string
+		origin string
+		code   int
+		allow  string
+	}{
+		{
+			desc:   "applies api host rules",
+			url:    "https://api.example.com/users",
+			origin: "https://app.example.com",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			desc:   "applies admin host rules",
+			url:    "https://admin.example.com/users",
+			origin: "https://admin.example.com",
+			code:   http.StatusOK,
+			allow:  "https://admin.example.com",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tC.url, nil)
+			req.Header.Set("Origin", tC.origin)
+			req.Header.Set("Access-Control-Request-Method", "DELETE")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tC.code, rr.Code)
+			assert.Equal(t, tC.allow, rr.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Package lambdacors applies CORS rules to AWS API Gateway proxy events of
+// Lambda functions. Request and Response have the fields of API Gateway REST
+// API proxy integration events used by CORS, they decode from the event JSON
+// and encode to the response JSON without the AWS Lambda SDK.
+package lambdacors
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/antklim/cors"
+)
+
+// Request is the API Gateway proxy request event.
+type Request struct {
+	Resource          string              `json:"resource"` // API Gateway resource, e.g. /users/{id}
+	Path              string              `json:"path"`
+	HTTPMethod        string              `json:"httpMethod"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// Response is the API Gateway proxy response.
+type Response struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded,omitempty"`
+}
+
+// Handler handles API Gateway proxy requests.
+type Handler func(context.Context, Request) (Response, error)
+
+// Result is the result of applying the rules to the request.
+type Result struct {
+	// Headers are the CORS headers of the response.
+	Headers map[string]string
+	// Response is the response of preflights and rejected requests, the
+	// function handler must not be called when it is set.
+	Response *Response
+}
+
+// Adapter applies the rules to API Gateway proxy requests.
+type Adapter struct {
+	rh *cors.RulesHandler
+}
+
+// New creates the adapter of the parsed rules. Rules paths are API Gateway
+// resources, such as /users/{id}, paths of requests without resources are
+// used otherwise.
+func New(rules *cors.Rules, opts ...cors.Option) *Adapter {
+	return &Adapter{rh: cors.NewRulesHandler(rules, opts...)}
+}
+
+// Evaluate applies the rule of the request resource to the request. Requests
+// DisallowedHandler option would handle are rejected with 403.
+func (a *Adapter) Evaluate(req Request) Result {
+	path := req.Resource
+	if path == "" {
+		path = req.Path
+	}
+
+	h := make(http.Header)
+	for k, v := range req.Headers {
+		h.Set(k, v)
+	}
+	for k, vv := range req.MultiValueHeaders {
+		h.Del(k)
+		for _, v := range vv {
+			h.Add(k, v)
+		}
+	}
+
+	out := a.rh.Compute(cors.Input{
+		Path:                  path,
+		Method:                req.HTTPMethod,
+		Host:                  h.Get("Host"),
+		Origin:                h.Get("Origin"),
+		RequestMethod:         h.Get("Access-Control-Request-Method"),
+		RequestHeaders:        h.Get("Access-Control-Request-Headers"),
+		RequestPrivateNetwork: h.Get("Access-Control-Request-Private-Network") == "true",
+	})
+
+	res := Result{Headers: headers(out)}
+	if out.ShortCircuit {
+		res.Response = &Response{
+			StatusCode: out.Status,
+			Headers:    res.Headers,
+		}
+	}
+	return res
+}
+
+// Wrap returns the handler applying the rules to the requests of h. Preflights
+// and rejected requests are answered without calling h, CORS headers are added
+// to the responses of h.
+func (a *Adapter) Wrap(h Handler) Handler {
+	return func(ctx context.Context, req Request) (Response, error) {
+		res := a.Evaluate(req)
+		if res.Response != nil {
+			return *res.Response, nil
+		}
+
+		resp, err := h(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		if resp.Headers == nil && len(res.Headers) > 0 {
+			resp.Headers = make(map[string]string, len(res.Headers))
+		}
+		for k, v := range res.Headers {
+			setHeader(resp.Headers, k, v)
+		}
+		return resp, nil
+	}
+}
+
+// headers returns the response headers of the output.
+func headers(out cors.Output) map[string]string {
+	if len(out.Headers) == 0 && len(out.Vary) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(out.Headers)+1)
+	for _, h := range out.Headers {
+		res[h.Name] = h.Value
+	}
+	if len(out.Vary) > 0 {
+		res["Vary"] = out.VaryValue()
+	}
+	return res
+}
+
+// setHeader sets the header of the response, Vary values are merged with the
+// values set by the function handler.
+func setHeader(h map[string]string, key, value string) {
+	for k, v := range h {
+		if !strings.EqualFold(k, key) {
+			continue
+		}
+
+		delete(h, k)
+		if strings.EqualFold(key, "Vary") {
+			value = cors.MergeVary(v, value)
+		}
+	}
+	h[key] = value
+}
@@ -0,0 +1,140 @@
+package lambdacors_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/antklim/cors/lambdacors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadEvent(t *testing.T, name string) lambdacors.Request {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	var req lambdacors.Request
+	require.NoError(t, json.Unmarshal(b, &req))
+	return req
+}
+
+func newAdapter(t *testing.T, opts ...cors.Option) *lambdacors.Adapter {
+	t.Helper()
+
+	rules := cors.NewRules("/users/{id};https://app.example.com;content-type;GET,PUT\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+	return lambdacors.New(rules, opts...)
+}
+
+func TestEvaluate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		event    string
+		opts     []cors.Option
+		headers  map[string]string
+		response *lambdacors.Response
+	}{
+		{
+			desc:  "answers preflight",
+			event: "preflight.json",
+			opts:  []cors.Option{cors.PreflightStatus(http.StatusNoContent)},
+			response: &lambdacors.Response{
+				StatusCode: http.StatusNoContent,
+				Headers: map[string]string{
+					"Access-Control-Allow-Origin":  "https://app.example.com",
+					"Access-Control-Allow-Methods": "PUT",
+					"Access-Control-Allow-Headers": "Content-Type",
+					"Vary":                         "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+				},
+			},
+		},
+		{
+			desc:  "returns headers of actual request",
+			event: "actual.json",
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "https://app.example.com",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			desc:  "rejects preflight of disallowed origin",
+			event: "disallowed.json",
+			opts:  []cors.Option{cors.RejectDisallowed()},
+			response: &lambdacors.Response{
+				StatusCode: http.StatusForbidden,
+				Headers:    map[string]string{"Vary": "Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			res := newAdapter(t, tC.opts...).Evaluate(loadEvent(t, tC.event))
+			if tC.response != nil {
+				require.NotNil(t, res.Response)
+				assert.Equal(t, tC.response.StatusCode, res.Response.StatusCode)
+				assert.Equal(t, tC.response.Headers, res.Response.Headers)
+				assert.Equal(t, tC.response.Headers, res.Headers)
+				return
+			}
+			assert.Nil(t, res.Response)
+			assert.Equal(t, tC.headers, res.Headers)
+		})
+	}
+}
+
+func TestEvaluateUsesPathWithoutResource(t *testing.T) {
+	req := lambdacors.Request{
+		Path:       "/projects",
+		HTTPMethod: http.MethodGet,
+		Headers:    map[string]string{"Origin": "https://foo.bar.org"},
+	}
+
+	res := newAdapter(t).Evaluate(req)
+	assert.Nil(t, res.Response)
+	assert.Equal(t, map[string]string{"Access-Control-Allow-Origin": "*"}, res.Headers)
+}
+
+func TestWrap(t *testing.T) {
+	called := 0
+	h := newAdapter(t).Wrap(func(ctx context.Context, req lambdacors.Request) (lambdacors.Response, error) {
+		called++
+		return lambdacors.Response{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"vary": "accept-encoding, origin"},
+			Body:       "OK",
+		}, nil
+	})
+
+	res, err := h(context.Background(), loadEvent(t, "preflight.json"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "https://app.example.com", res.Headers["Access-Control-Allow-Origin"])
+	assert.Zero(t, called)
+
+	res, err = h(context.Background(), loadEvent(t, "actual.json"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, lambdacors.Response{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "https://app.example.com",
+			"Vary":                        "accept-encoding, origin",
+		},
+		Body: "OK",
+	}, res)
+
+	b, err := json.Marshal(res)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"statusCode": 200,
+		"headers": {"Access-Control-Allow-Origin": "https://app.example.com", "Vary": "accept-encoding, origin"},
+		"body": "OK"
+	}`, string(b))
+}
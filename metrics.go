@@ -0,0 +1,190 @@
+package cors
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricsCollector collects metrics of CORS decisions by the path of the
+// applied rule.
+type MetricsCollector interface {
+	IncPreflights(path string)
+	IncAllowed(path string)
+	IncDenied(path string, reason Reason)
+	IncRuleHits(path string)
+	ObserveDuration(path string, d time.Duration)
+}
+
+// MetricsObserver returns the observer passing CORS decisions to the collector.
+// Metrics are collected by the path of the applied rule, requests of paths
+// without own rules are collected by the wildcard rule path.
+func MetricsObserver(c MetricsCollector) Observer {
+	return ObserverFunc(func(d Decision) {
+		c.IncRuleHits(d.RulePath)
+		if d.Preflight {
+			c.IncPreflights(d.RulePath)
+		}
+
+		switch d.Outcome {
+		case OutcomeAllowed:
+			c.IncAllowed(d.RulePath)
+		case OutcomeDenied:
+			c.IncDenied(d.RulePath, d.Reason)
+		case OutcomeSkipped:
+		}
+
+		c.ObserveDuration(d.RulePath, d.Duration)
+	})
+}
+
+// DefaultDurationBuckets are upper bounds of MemoryMetrics duration histogram
+// buckets. Rules evaluation takes microseconds, it does not include the next
+// handler.
+var DefaultDurationBuckets = []time.Duration{
+	250 * time.Nanosecond,
+	500 * time.Nanosecond,
+	time.Microsecond,
+	2500 * time.Nanosecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	25 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+}
+
+// Histogram is a snapshot of durations histogram.
+type Histogram struct {
+	Buckets []time.Duration // upper bounds of buckets
+	Counts  []uint64        // cumulative counts of observations in buckets
+	Count   uint64          // total count of observations
+	Sum     time.Duration   // total duration of observations
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	for i, b := range h.Buckets {
+		if d <= b {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += d
+}
+
+// MetricsSnapshot holds the metrics collected by MemoryMetrics.
+type MetricsSnapshot struct {
+	Preflights map[string]uint64            // preflights by rule path
+	Allowed    map[string]uint64            // allowed requests by rule path
+	Denied     map[string]map[Reason]uint64 // denied requests by rule path and reason
+	RuleHits   map[string]uint64            // handled requests by rule path
+	Durations  map[string]Histogram         // rules evaluation durations by rule path
+}
+
+// MemoryMetrics is the in-memory metrics collector, it is safe for concurrent use.
+type MemoryMetrics struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	m       MetricsSnapshot
+}
+
+// NewMemoryMetrics creates in-memory metrics collector with the duration
+// histogram buckets, DefaultDurationBuckets are used when no buckets provided.
+func NewMemoryMetrics(buckets ...time.Duration) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+
+	b := append([]time.Duration{}, buckets...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+
+	return &MemoryMetrics{
+		buckets: b,
+		m:       newMetricsSnapshot(),
+	}
+}
+
+func newMetricsSnapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		Preflights: make(map[string]uint64),
+		Allowed:    make(map[string]uint64),
+		Denied:     make(map[string]map[Reason]uint64),
+		RuleHits:   make(map[string]uint64),
+		Durations:  make(map[string]Histogram),
+	}
+}
+
+func (m *MemoryMetrics) IncPreflights(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m.Preflights[path]++
+}
+
+func (m *MemoryMetrics) IncAllowed(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m.Allowed[path]++
+}
+
+func (m *MemoryMetrics) IncDenied(path string, reason Reason) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m.Denied[path] == nil {
+		m.m.Denied[path] = make(map[Reason]uint64)
+	}
+	m.m.Denied[path][reason]++
+}
+
+func (m *MemoryMetrics) IncRuleHits(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m.RuleHits[path]++
+}
+
+func (m *MemoryMetrics) ObserveDuration(path string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.m.Durations[path]
+	if !ok {
+		h = Histogram{
+			Buckets: m.buckets,
+			Counts:  make([]uint64, len(m.buckets)),
+		}
+	}
+	h.observe(d)
+	m.m.Durations[path] = h
+}
+
+// Snapshot returns a copy of the collected metrics.
+func (m *MemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := newMetricsSnapshot()
+	for k, v := range m.m.Preflights {
+		s.Preflights[k] = v
+	}
+	for k, v := range m.m.Allowed {
+		s.Allowed[k] = v
+	}
+	for k, v := range m.m.Denied {
+		s.Denied[k] = make(map[Reason]uint64, len(v))
+		for r, c := range v {
+			s.Denied[k][r] = c
+		}
+	}
+	for k, v := range m.m.RuleHits {
+		s.RuleHits[k] = v
+	}
+	for k, v := range m.m.Durations {
+		v.Counts = append([]uint64{}, v.Counts...)
+		s.Durations[k] = v
+	}
+	return s
+}
+
+// Reset clears the collected metrics.
+func (m *MemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m = newMetricsSnapshot()
+}
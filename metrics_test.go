@@ -0,0 +1,90 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryMetrics(t *testing.T) {
+	m := cors.NewMemoryMetrics()
+
+	h, err := cors.OptionsRoutes([]string{"/a", "/b"}, "/a;https://foo.bar.org;;PUT\n*;;;*",
+		cors.DecisionObserver(cors.MetricsObserver(m)))
+	require.NoError(t, err)
+
+	requests := []struct {
+		path   string
+		origin string
+		method string
+	}{
+		{path: "/a", origin: "https://foo.bar.org", method: "PUT"},
+		{path: "/a", origin: "https://foo.bar.org", method: "PUT"},
+		{path: "/a", origin: "https://foo.bar.org", method: "DELETE"},
+		{path: "/a", origin: "https://bar.foo.org", method: "PUT"},
+		{path: "/b", origin: "https://bar.foo.org", method: "PUT"},
+		{path: "/b", method: "PUT"},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(http.MethodOptions, r.path, nil)
+		if r.origin != "" {
+			req.Header.Set("Origin", r.origin)
+		}
+		req.Header.Set("Access-Control-Request-Method", r.method)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	s := m.Snapshot()
+	assert.Equal(t, map[string]uint64{"/a": 4, "*": 2}, s.Preflights)
+	assert.Equal(t, map[string]uint64{"/a": 2, "*": 1}, s.Allowed)
+	assert.Equal(t, map[string]map[cors.Reason]uint64{
+		"/a": {
+			cors.ReasonMethodNotAllowed: 1,
+			cors.ReasonOriginNotAllowed: 1,
+		},
+	}, s.Denied)
+	assert.Equal(t, map[string]uint64{"/a": 4, "*": 2}, s.RuleHits)
+	assert.Equal(t, uint64(4), s.Durations["/a"].Count)
+	assert.Equal(t, uint64(2), s.Durations["*"].Count)
+
+	m.Reset()
+	assert.Empty(t, m.Snapshot().RuleHits)
+}
+
+func TestMemoryMetricsWildcardRulePath(t *testing.T) {
+	m := cors.NewMemoryMetrics()
+
+	rules := cors.NewRules("/a;https://foo.bar.org;;PUT\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+	rh := cors.NewRulesHandler(rules, cors.DecisionObserver(cors.MetricsObserver(m)))
+
+	for _, p := range []string{"/a", "/users/1", "/users/2", "/users/3"} {
+		rh.Compute(cors.Input{Path: p, Method: http.MethodGet, Origin: "https://foo.bar.org"})
+	}
+
+	assert.Equal(t, map[string]uint64{"/a": 1, "*": 3}, m.Snapshot().RuleHits)
+}
+
+func TestMemoryMetricsDurations(t *testing.T) {
+	m := cors.NewMemoryMetrics(time.Second, time.Millisecond)
+	m.ObserveDuration("/a", 500*time.Microsecond)
+	m.ObserveDuration("/a", 2*time.Millisecond)
+	m.ObserveDuration("/a", 2*time.Second)
+
+	s := m.Snapshot()
+	assert.Equal(t, cors.Histogram{
+		Buckets: []time.Duration{time.Millisecond, time.Second},
+		Counts:  []uint64{1, 2},
+		Count:   3,
+		Sum:     2*time.Second + 2500*time.Microsecond,
+	}, s.Durations["/a"])
+
+	// snapshot is a copy
+	m.ObserveDuration("/a", time.Microsecond)
+	assert.Equal(t, []uint64{1, 2}, s.Durations["/a"].Counts)
+}
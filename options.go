@@ -0,0 +1,107 @@
+package cors
+
+import "net/http"
+
+// disallowedPolicy defines how handlers respond to requests from disallowed origins.
+type disallowedPolicy int
+
+const (
+	disallowedPass   disallowedPolicy = iota // pass actual requests without CORS headers
+	disallowedReject                         // respond with 403
+	disallowedHandle                         // call the disallowed handler
+)
+
+// Option configures CORS handlers created by OptionsRoutes and Middleware.
+// Rule settings take precedence over the options.
+type Option func(*options)
+
+type options struct {
+	status int          // preflight response status, 0 - not set
+	pt     bool         // pass preflight through to the next handler
+	next   http.Handler // next handler of OptionsRoutes preflights
+	dp     disallowedPolicy
+	dh     http.Handler // disallowed origins handler
+	obs    []Observer
+	ro     *reportOnlyOptions
+	dm     bool // derive allowed methods from router routes
+}
+
+func newOptions(opts ...Option) options {
+	o := options{next: noopHTTPHandler}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// PreflightStatus sets the status code of successful preflight responses.
+// Codes out of 2xx range are ignored. Default status is 200.
+func PreflightStatus(code int) Option {
+	return func(o *options) {
+		if isPreflightStatus(code) {
+			o.status = code
+		}
+	}
+}
+
+// PreflightPassthrough passes preflight requests to the next handler after
+// CORS headers are set, the next handler writes the response. OPTIONS requests
+// without requested method are passed on as actual requests, OPTIONS requests
+// without allowed origin are passed on too, unless the disallowed policy
+// rejects or handles them.
+func PreflightPassthrough() Option {
+	return func(o *options) {
+		o.pt = true
+	}
+}
+
+// PreflightHandler sets the handler OptionsRoutes passes preflight requests
+// through to. It has no effect on Middleware.
+func PreflightHandler(h http.Handler) Option {
+	return func(o *options) {
+		if h != nil {
+			o.next = h
+		}
+	}
+}
+
+// RejectDisallowed responds with 403 to preflight and actual requests from
+// disallowed origins. By default actual requests are passed to the next
+// handler without CORS headers and preflights are not answered.
+// Requests from the same origin as the request host are not rejected.
+func RejectDisallowed() Option {
+	return func(o *options) {
+		o.dp = disallowedReject
+	}
+}
+
+// DisallowedHandler sets the handler of preflight and actual requests from
+// disallowed origins. Requests from the same origin as the request host are
+// not passed to the handler.
+func DisallowedHandler(h http.Handler) Option {
+	return func(o *options) {
+		if h != nil {
+			o.dp = disallowedHandle
+			o.dh = h
+		}
+	}
+}
+
+// DecisionObserver adds the observer of CORS decisions.
+func DecisionObserver(obs Observer) Option {
+	return func(o *options) {
+		if obs != nil {
+			o.obs = append(o.obs, obs)
+		}
+	}
+}
+
+// DerivedMethods limits allowed methods of rules to the methods of the router
+// routes of the path, so preflights advertise only the methods the path
+// serves. Routes without methods matchers serve any method. It has effect on
+// Attach only.
+func DerivedMethods() Option {
+	return func(o *options) {
+		o.dm = true
+	}
+}
@@ -0,0 +1,114 @@
+// Package promcors provides Prometheus collector of CORS decisions metrics.
+package promcors
+
+import (
+	"time"
+
+	"github.com/antklim/cors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const subsystem = "cors"
+
+// Collector collects CORS metrics, it implements both cors.MetricsCollector
+// and prometheus.Collector.
+type Collector struct {
+	preflights *prometheus.CounterVec
+	allowed    *prometheus.CounterVec
+	denied     *prometheus.CounterVec
+	hits       *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+var _ cors.MetricsCollector = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// DefaultBuckets are upper bounds in seconds of duration histogram buckets,
+// they are cors.DefaultDurationBuckets.
+var DefaultBuckets = secondsOf(cors.DefaultDurationBuckets)
+
+// NewCollector creates the collector with metrics in the namespace.
+// Duration histogram uses DefaultBuckets when no buckets provided.
+func NewCollector(namespace string, buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	return &Collector{
+		preflights: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "preflight_requests_total",
+			Help:      "Total number of CORS preflight requests.",
+		}, []string{"path"}),
+		allowed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "allowed_requests_total",
+			Help:      "Total number of allowed CORS requests.",
+		}, []string{"path"}),
+		denied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "denied_requests_total",
+			Help:      "Total number of denied CORS requests.",
+		}, []string{"path", "reason"}),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rule_hits_total",
+			Help:      "Total number of requests handled by CORS rules.",
+		}, []string{"path"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "evaluation_duration_seconds",
+			Help:      "Duration of CORS rules evaluation of requests.",
+			Buckets:   buckets,
+		}, []string{"path"}),
+	}
+}
+
+func (c *Collector) IncPreflights(path string) {
+	c.preflights.WithLabelValues(path).Inc()
+}
+
+func (c *Collector) IncAllowed(path string) {
+	c.allowed.WithLabelValues(path).Inc()
+}
+
+func (c *Collector) IncDenied(path string, reason cors.Reason) {
+	c.denied.WithLabelValues(path, string(reason)).Inc()
+}
+
+func (c *Collector) IncRuleHits(path string) {
+	c.hits.WithLabelValues(path).Inc()
+}
+
+func (c *Collector) ObserveDuration(path string, d time.Duration) {
+	c.duration.WithLabelValues(path).Observe(d.Seconds())
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.preflights.Describe(ch)
+	c.allowed.Describe(ch)
+	c.denied.Describe(ch)
+	c.hits.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.preflights.Collect(ch)
+	c.allowed.Collect(ch)
+	c.denied.Collect(ch)
+	c.hits.Collect(ch)
+	c.duration.Collect(ch)
+}
+
+func secondsOf(dd []time.Duration) []float64 {
+	s := make([]float64, 0, len(dd))
+	for _, d := range dd {
+		s = append(s, d.Seconds())
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+package promcors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/antklim/cors/promcors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	c := promcors.NewCollector("test")
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(c))
+
+	h, err := cors.OptionsRoutes([]string{"/a"}, "/a;https://foo.bar.org;;PUT",
+		cors.DecisionObserver(cors.MetricsObserver(c)))
+	require.NoError(t, err)
+
+	for _, origin := range []string{"https://foo.bar.org", "https://bar.foo.org", "https://bar.foo.org"} {
+		req := httptest.NewRequest(http.MethodOptions, "/a", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := `
+# HELP test_cors_allowed_requests_total Total number of allowed CORS requests.
+# TYPE test_cors_allowed_requests_total counter
+test_cors_allowed_requests_total{path="/a"} 1
+# HELP test_cors_denied_requests_total Total number of denied CORS requests.
+# TYPE test_cors_denied_requests_total counter
+test_cors_denied_requests_total{path="/a",reason="origin not allowed"} 2
+# HELP test_cors_preflight_requests_total Total number of CORS preflight requests.
+# TYPE test_cors_preflight_requests_total counter
+test_cors_preflight_requests_total{path="/a"} 3
+# HELP test_cors_rule_hits_total Total number of requests handled by CORS rules.
+# TYPE test_cors_rule_hits_total counter
+test_cors_rule_hits_total{path="/a"} 3
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"test_cors_allowed_requests_total",
+		"test_cors_denied_requests_total",
+		"test_cors_preflight_requests_total",
+		"test_cors_rule_hits_total",
+	)
+	assert.NoError(t, err)
+
+	n, err := testutil.GatherAndCount(reg, "test_cors_evaluation_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestDefaultBuckets(t *testing.T) {
+	assert.Equal(t, []float64{2.5e-7, 5e-7, 1e-6, 2.5e-6, 5e-6, 1e-5, 2.5e-5, 5e-5, 1e-4}, promcors.DefaultBuckets)
+}
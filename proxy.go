@@ -0,0 +1,92 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+)
+
+const corsHeadersPrefix = "Access-Control-"
+
+// ProxyHandler returns the handler applying the rules to the requests proxied
+// by p. Preflights are answered by the handler, they are forwarded to the
+// upstream with PreflightPassthrough option or rule setting. Access-Control-*
+// headers of upstream responses are replaced by the headers of the rules.
+// The rule is resolved by the path of pathOf of the inbound request, by the
+// request path when pathOf is nil. The handler does not change p.
+func ProxyHandler(p *httputil.ReverseProxy, rules *Rules, pathOf PathFunc, opts ...Option) http.Handler {
+	if pathOf == nil {
+		pathOf = RequestPath
+	}
+
+	rh := NewRulesHandler(rules, opts...)
+
+	proxy := *p
+	modify := p.ModifyResponse
+	proxy.ModifyResponse = func(res *http.Response) error {
+		if modify != nil {
+			if err := modify(res); err != nil {
+				return err
+			}
+		}
+		// CORS headers are set by the rules handler
+		stripCORSHeaders(res.Header)
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rh.Serve(pathOf(r), w, r, &proxy)
+	})
+}
+
+// ModifyResponse returns httputil.ReverseProxy ModifyResponse function
+// replacing Access-Control-* headers of upstream responses by the headers of
+// the rules. Preflights are forwarded to the upstream, the upstream response
+// status is replaced by the status of the preflight decision unless it is
+// passed through.
+//
+// The rule is resolved by the path of pathOf, by the request path when pathOf
+// is nil. ModifyResponse sees the outbound request, after the proxy Director
+// or Rewrite changed it: when they rewrite paths, pathOf must return the
+// inbound path, such as the path with the stripped prefix, to resolve the
+// same rules as ProxyHandler.
+func ModifyResponse(rules *Rules, pathOf PathFunc, opts ...Option) func(*http.Response) error {
+	if pathOf == nil {
+		pathOf = RequestPath
+	}
+
+	rh := NewRulesHandler(rules, opts...)
+	return func(res *http.Response) error {
+		out := rh.Compute(inputOf(pathOf(res.Request), res.Request))
+
+		stripCORSHeaders(res.Header)
+		for _, h := range out.Headers {
+			res.Header.Set(h.Name, h.Value)
+		}
+		addVary(res.Header, out.Vary...)
+
+		if out.ShortCircuit {
+			res.StatusCode = out.Status
+			res.Status = fmt.Sprintf("%d %s", out.Status, http.StatusText(out.Status))
+			if res.Body != nil {
+				res.Body.Close()
+			}
+			res.Body = http.NoBody
+			res.ContentLength = 0
+			res.Header.Del("Content-Length")
+			res.Header.Del("Content-Type")
+		}
+
+		return nil
+	}
+}
+
+// stripCORSHeaders deletes Access-Control-* headers.
+func stripCORSHeaders(h http.Header) {
+	for k := range h {
+		if strings.HasPrefix(http.CanonicalHeaderKey(k), corsHeadersPrefix) {
+			delete(h, k)
+		}
+	}
+}
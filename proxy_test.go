@@ -0,0 +1,233 @@
+package cors_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyUpstream responds with its own wrong CORS headers.
+func legacyUpstream(t *testing.T, requests *[]string) *url.URL {
+	t.Helper()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+		w.Header().Set("access-control-allow-credentials", "true")
+		w.Header().Set("Vary", "Accept-Encoding")
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "upstream")
+	}))
+	t.Cleanup(upstream.Close)
+
+	u, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+	return u
+}
+
+func newProxyRequest(method, path string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestProxyHandler(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;content-type;PUT\n/b;*;;GET;passthrough")
+	require.NoError(t, rules.Parse())
+
+	var requests []string
+	h := cors.ProxyHandler(httputil.NewSingleHostReverseProxy(legacyUpstream(t, &requests)), rules, nil,
+		cors.PreflightStatus(http.StatusNoContent))
+
+	testCases := []struct {
+		desc     string
+		method   string
+		path     string
+		headers  map[string]string
+		status   int
+		allow    map[string]string
+		vary     []string
+		body     string
+		upstream []string
+	}{
+		{
+			desc:   "answers preflight",
+			method: http.MethodOptions,
+			path:   "/a",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			status: http.StatusNoContent,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":      "https://foo.bar.org",
+				"Access-Control-Allow-Methods":     "PUT",
+				"Access-Control-Allow-Credentials": "",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "rejects preflight",
+			method: http.MethodOptions,
+			path:   "/a",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusMethodNotAllowed,
+			allow:  map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:   []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "forwards passed through preflight",
+			method: http.MethodOptions,
+			path:   "/b",
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "GET",
+			},
+			status: http.StatusOK,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "",
+			},
+			vary:     []string{"Accept-Encoding", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+			body:     "upstream",
+			upstream: []string{"OPTIONS /b"},
+		},
+		{
+			desc:    "replaces upstream headers of actual request",
+			method:  http.MethodPut,
+			path:    "/a",
+			headers: map[string]string{"Origin": "https://foo.bar.org"},
+			status:  http.StatusOK,
+			allow: map[string]string{
+				"Access-Control-Allow-Origin":      "https://foo.bar.org",
+				"Access-Control-Allow-Methods":     "",
+				"Access-Control-Allow-Credentials": "",
+			},
+			vary:     []string{"Accept-Encoding", "Origin"},
+			body:     "upstream",
+			upstream: []string{"PUT /a"},
+		},
+		{
+			desc:     "strips upstream headers of actual request from disallowed origin",
+			method:   http.MethodPut,
+			path:     "/a",
+			headers:  map[string]string{"Origin": "https://bar.foo.org"},
+			status:   http.StatusOK,
+			allow:    map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:     []string{"Accept-Encoding", "Origin"},
+			body:     "upstream",
+			upstream: []string{"PUT /a"},
+		},
+		{
+			desc:     "strips upstream headers of path without rule",
+			method:   http.MethodGet,
+			path:     "/c",
+			headers:  map[string]string{"Origin": "https://foo.bar.org"},
+			status:   http.StatusOK,
+			allow:    map[string]string{"Access-Control-Allow-Origin": ""},
+			vary:     []string{"Accept-Encoding"},
+			body:     "upstream",
+			upstream: []string{"GET /c"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			requests = nil
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, newProxyRequest(tC.method, tC.path, tC.headers))
+
+			assert.Equal(t, tC.status, rr.Code)
+			for k, v := range tC.allow {
+				assert.Equal(t, v, rr.Header().Get(k), k)
+			}
+			assert.Equal(t, tC.vary, rr.Header().Values("Vary"))
+			assert.Equal(t, tC.body, rr.Body.String())
+			assert.Equal(t, tC.upstream, requests)
+		})
+	}
+}
+
+func TestModifyResponse(t *testing.T) {
+	rules := cors.NewRules("/a;https://foo.bar.org;content-type;PUT")
+	require.NoError(t, rules.Parse())
+
+	var requests []string
+	p := httputil.NewSingleHostReverseProxy(legacyUpstream(t, &requests))
+	p.ModifyResponse = cors.ModifyResponse(rules, nil)
+
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, newProxyRequest(http.MethodPut, "/a", map[string]string{"Origin": "https://foo.bar.org"}))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://foo.bar.org", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, []string{"Accept-Encoding", "Origin"}, rr.Header().Values("Vary"))
+	assert.Equal(t, "upstream", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, newProxyRequest(http.MethodOptions, "/a", map[string]string{
+		"Origin":                        "https://foo.bar.org",
+		"Access-Control-Request-Method": "DELETE",
+	}))
+	res := rr.Result()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, body)
+	assert.Equal(t, []string{"PUT /a", "OPTIONS /a"}, requests)
+}
+
+func TestModifyResponsePathFunc(t *testing.T) {
+	rules := cors.NewRules("/api/a;https://foo.bar.org;;PUT")
+	require.NoError(t, rules.Parse())
+
+	var requests []string
+	p := httputil.NewSingleHostReverseProxy(legacyUpstream(t, &requests))
+	director := p.Director
+	p.Director = func(r *http.Request) {
+		director(r)
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/api")
+	}
+
+	testCases := []struct {
+		desc   string
+		pathOf cors.PathFunc
+		origin string
+	}{
+		{
+			desc:   "resolves rule by outbound path",
+			origin: "",
+		},
+		{
+			desc:   "resolves rule by path func",
+			pathOf: func(r *http.Request) string { return "/api" + r.URL.Path },
+			origin: "https://foo.bar.org",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			p.ModifyResponse = cors.ModifyResponse(rules, tC.pathOf)
+
+			rr := httptest.NewRecorder()
+			p.ServeHTTP(rr, newProxyRequest(http.MethodPut, "/api/a", map[string]string{"Origin": "https://foo.bar.org"}))
+			assert.Equal(t, tC.origin, rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "PUT /a", requests[len(requests)-1])
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Registrar registers handlers of OPTIONS requests in a router.
+type Registrar interface {
+	HandleOptions(path string, h http.Handler)
+}
+
+// RegistrarFunc is an adapter to use functions as registrars.
+type RegistrarFunc func(path string, h http.Handler)
+
+func (f RegistrarFunc) HandleOptions(path string, h http.Handler) {
+	f(path, h)
+}
+
+// ServeMuxRegistrar returns the registrar of http.ServeMux. Handlers are
+// registered with "OPTIONS path" patterns, paths can have wildcards such as
+// /users/{id}.
+func ServeMuxRegistrar(m *http.ServeMux) Registrar {
+	return RegistrarFunc(func(path string, h http.Handler) {
+		m.Handle(http.MethodOptions+" "+path, h)
+	})
+}
+
+// MuxRegistrar returns the registrar of gorilla/mux router.
+func MuxRegistrar(router *mux.Router) Registrar {
+	return RegistrarFunc(func(path string, h http.Handler) {
+		router.Handle(path, h).Methods(http.MethodOptions)
+	})
+}
+
+// RegisterOptionsRoutes registers preflight handlers of the paths with the
+// registrar. Paths without explicit rules use the wildcard rule, paths
+// without rules are not registered.
+func RegisterOptionsRoutes(reg Registrar, paths []string, r *Rules, opts ...Option) error {
+	if len(paths) == 0 {
+		return errors.New("invalid paths list: cannot be empty")
+	}
+
+	registerOptionsRoutes(reg, paths, r, newOptions(opts...))
+	return nil
+}
+
+func registerOptionsRoutes(reg Registrar, paths []string, r *Rules, o options) {
+	// routers such as http.ServeMux reject repeated registrations
+	registered := make(map[string]bool)
+	register := func(path string, rule Rule) {
+		if !registered[path] {
+			registered[path] = true
+			h := newHandler(path, rule, o.next, o)
+			h.rp = r.rulePath(path)
+			reg.HandleOptions(path, h)
+		}
+	}
+
+	// r.op has only unique paths and a wildacrd if presented
+	// only the first occurrence of the path configuration applied
+	for _, p := range r.op {
+		rule := r.pr[p]
+		if p == wildcard {
+			for _, pp := range paths {
+				register(pp, rule)
+			}
+			break
+		}
+
+		found, _ := find(paths, p)
+		if found {
+			register(p, rule)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterOptionsRoutes(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config string
+		paths  []string
+		want   []string
+	}{
+		{
+			desc:   "registers paths with explicit rules",
+			config: "/a;*;;GET\n/b;*;;GET",
+			paths:  []string{"/b", "/c"},
+			want:   []string{"/b"},
+		},
+		{
+			desc:   "registers paths with wildcard rule",
+			config: "/a;*;;GET\n*;*;;GET",
+			paths:  []string{"/a", "/b", "/c", "/b"},
+			want:   []string{"/a", "/b", "/c"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rules := cors.NewRules(tC.config)
+			require.NoError(t, rules.Parse())
+
+			var got []string
+			reg := cors.RegistrarFunc(func(path string, h http.Handler) {
+				got = append(got, path)
+			})
+			err := cors.RegisterOptionsRoutes(reg, tC.paths, rules)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestRegisterOptionsRoutesFails(t *testing.T) {
+	rules := cors.NewRules("*;*;;GET")
+	require.NoError(t, rules.Parse())
+
+	reg := cors.RegistrarFunc(func(path string, h http.Handler) {})
+	err := cors.RegisterOptionsRoutes(reg, nil, rules)
+	assert.EqualError(t, err, "invalid paths list: cannot be empty")
+}
+
+func TestRegistrars(t *testing.T) {
+	rules := cors.NewRules("/users/{id};https://foo.bar.org;content-type;PUT\n*;*;;GET")
+	require.NoError(t, rules.Parse())
+	paths := []string{"/users/{id}", "/projects"}
+
+	serveMux := http.NewServeMux()
+	router := mux.NewRouter()
+
+	testCases := []struct {
+		desc string
+		reg  cors.Registrar
+		h    http.Handler
+	}{
+		{
+			desc: "http.ServeMux",
+			reg:  cors.ServeMuxRegistrar(serveMux),
+			h:    serveMux,
+		},
+		{
+			desc: "gorilla/mux",
+			reg:  cors.MuxRegistrar(router),
+			h:    router,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := cors.RegisterOptionsRoutes(tC.reg, paths, rules, cors.PreflightStatus(http.StatusNoContent))
+			require.NoError(t, err)
+
+			preflight := func(path, method string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodOptions, path, nil)
+				req.Header.Set("Origin", "https://foo.bar.org")
+				req.Header.Set("Access-Control-Request-Method", method)
+				rr := httptest.NewRecorder()
+				tC.h.ServeHTTP(rr, req)
+				return rr
+			}
+
+			rr := preflight("/users/1", http.MethodPut)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			assert.Equal(t, "https://foo.bar.org", rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "PUT", rr.Header().Get("Access-Control-Allow-Methods"))
+
+			rr = preflight("/users/1", http.MethodDelete)
+			assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+			rr = preflight("/projects", http.MethodGet)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+
+			rr = preflight("/orders", http.MethodGet)
+			assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
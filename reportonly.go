@@ -0,0 +1,94 @@
+package cors
+
+import "net/http"
+
+// Mismatch holds the decisions of enforced and candidate rules which differ
+// in outcome or reason for the same request.
+type Mismatch struct {
+	Enforced  Decision
+	Candidate Decision
+}
+
+// Reporter receives mismatches of report-only rules.
+type Reporter interface {
+	Report(Mismatch)
+}
+
+// ReporterFunc is an adapter to use functions as reporters.
+type ReporterFunc func(Mismatch)
+
+func (f ReporterFunc) Report(m Mismatch) {
+	f(m)
+}
+
+type reportOnlyOptions struct {
+	rules *Rules
+	rep   Reporter
+}
+
+// ReportOnly evaluates the parsed candidate rules alongside the enforced rule
+// of each request and reports the mismatches. Candidate rules are not enforced.
+func ReportOnly(candidate *Rules, rep Reporter) Option {
+	return func(o *options) {
+		if candidate != nil && rep != nil {
+			o.ro = &reportOnlyOptions{rules: candidate, rep: rep}
+		}
+	}
+}
+
+// reportOnly evaluates the candidate rule of the path.
+type reportOnly struct {
+	path string
+	h    *handler // nil when candidate rules have no rule of the path
+	rep  Reporter
+}
+
+func newReportOnly(path string, opts options) *reportOnly {
+	ro := &reportOnly{path: path, rep: opts.ro.rep}
+
+	if rule, ok := opts.ro.rules.OfPath(path); ok {
+		copts := opts
+		copts.obs = nil
+		copts.ro = nil
+		ro.h = newHandler(path, rule, nil, copts)
+		ro.h.rp = opts.ro.rules.rulePath(path)
+	}
+
+	return ro
+}
+
+// compare evaluates the candidate rule and reports its decision when it
+// differs from the enforced one.
+func (ro *reportOnly) compare(enforced Decision, in Input) {
+	var c Decision
+	if ro.h != nil {
+		c = ro.h.evaluate(in).d
+	} else {
+		c = noRuleDecision(in)
+	}
+
+	if c.Outcome != enforced.Outcome || c.Reason != enforced.Reason {
+		ro.rep.Report(Mismatch{Enforced: enforced, Candidate: c})
+	}
+}
+
+// noRuleDecision returns the decision of the request to the path without rule.
+func noRuleDecision(in Input) Decision {
+	d := Decision{
+		Path:      in.Path,
+		Preflight: in.Method == http.MethodOptions,
+		Origin:    in.Origin,
+		Method:    in.Method,
+	}
+
+	switch {
+	case d.Origin == "":
+		d.skip(ReasonNoOrigin)
+	case isSameOrigin(d.Origin, in.Host):
+		d.skip(ReasonSameOrigin)
+	default:
+		d.deny(ReasonNoRule)
+	}
+
+	return d
+}
@@ -0,0 +1,141 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportOnly(t *testing.T) {
+	candidate := cors.NewRules("/a;https://foo.bar.org;;PUT")
+	require.NoError(t, candidate.Parse())
+
+	testCases := []struct {
+		desc   string
+		path   string
+		origin string
+		method string
+		code   int
+		want   []cors.Mismatch
+	}{
+		{
+			desc:   "does not report matching decisions",
+			path:   "/a",
+			origin: "https://foo.bar.org",
+			method: "PUT",
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "does not report skipped requests",
+			path:   "/b",
+			method: "PUT",
+			code:   http.StatusOK,
+		},
+		{
+			desc:   "reports origin not allowed by candidate",
+			path:   "/a",
+			origin: "https://bar.foo.org",
+			method: "PUT",
+			code:   http.StatusOK,
+			want: []cors.Mismatch{{
+				Enforced: cors.Decision{
+					Outcome: cors.OutcomeAllowed,
+					Reason:  cors.ReasonNone,
+				},
+				Candidate: cors.Decision{
+					Outcome: cors.OutcomeDenied,
+					Reason:  cors.ReasonOriginNotAllowed,
+				},
+			}},
+		},
+		{
+			desc:   "reports method not allowed by candidate",
+			path:   "/a",
+			origin: "https://foo.bar.org",
+			method: "DELETE",
+			code:   http.StatusOK,
+			want: []cors.Mismatch{{
+				Enforced: cors.Decision{
+					Outcome: cors.OutcomeAllowed,
+					Reason:  cors.ReasonNone,
+				},
+				Candidate: cors.Decision{
+					Outcome: cors.OutcomeDenied,
+					Reason:  cors.ReasonMethodNotAllowed,
+				},
+			}},
+		},
+		{
+			desc:   "reports path without candidate rule",
+			path:   "/b",
+			origin: "https://foo.bar.org",
+			method: "PUT",
+			code:   http.StatusOK,
+			want: []cors.Mismatch{{
+				Enforced: cors.Decision{
+					Outcome: cors.OutcomeAllowed,
+					Reason:  cors.ReasonNone,
+				},
+				Candidate: cors.Decision{
+					Outcome: cors.OutcomeDenied,
+					Reason:  cors.ReasonNoRule,
+				},
+			}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var got []cors.Mismatch
+			rep := cors.ReporterFunc(func(m cors.Mismatch) {
+				got = append(got, cors.Mismatch{
+					Enforced:  cors.Decision{Outcome: m.Enforced.Outcome, Reason: m.Enforced.Reason},
+					Candidate: cors.Decision{Outcome: m.Candidate.Outcome, Reason: m.Candidate.Reason},
+				})
+				assert.Equal(t, tC.path, m.Enforced.Path)
+				assert.Equal(t, tC.path, m.Candidate.Path)
+			})
+
+			h, err := cors.OptionsRoutes([]string{"/a", "/b"}, "*;*;;*", cors.ReportOnly(candidate, rep))
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodOptions, tC.path, nil)
+			if tC.origin != "" {
+				req.Header.Set("Origin", tC.origin)
+			}
+			req.Header.Set("Access-Control-Request-Method", tC.method)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			assert.Equal(t, tC.code, res.StatusCode)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func TestReportOnlyDoesNotEnforceCandidate(t *testing.T) {
+	candidate := cors.NewRules("*;https://foo.bar.org;;GET")
+	require.NoError(t, candidate.Parse())
+
+	var reports int
+	rep := cors.ReporterFunc(func(m cors.Mismatch) { reports++ })
+
+	rule := cors.NewRuleBuilder().WithOrigins("*").WithMethods(http.MethodGet).Build()
+	h := cors.Middleware("/a", rule, cors.ReportOnly(candidate, rep))(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	req.Header.Set("Origin", "https://bar.foo.org")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	res := rr.Result()
+	assert.Equal(t, "*", res.Header.Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, res.Header.Values("Vary"))
+	assert.Equal(t, 1, reports)
+}
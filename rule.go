@@ -3,6 +3,7 @@ package cors
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -11,6 +12,9 @@ const (
 	fieldsDlm string = ";"
 	valuesDlm string = ","
 
+	commentMark  string = "#"
+	continuation string = "\\"
+
 	wildcard string = "*"
 )
 
@@ -19,7 +23,18 @@ const (
 	oIdx
 	hIdx
 	mIdx
-	fNum // a number of fields in the rule, must be last
+	xIdx // rule options, optional
+	fNum // a maximum number of fields in the rule, must be last
+)
+
+const fMin = xIdx // a number of mandatory fields in the rule
+
+const (
+	optPrivateNetwork string = "private-network"
+	optPassthrough    string = "passthrough"
+	optStatus         string = "status"
+	optExpose         string = "expose"
+	optValueDlm       string = "="
 )
 
 const parseErr string = "invalid cors rules"
@@ -42,12 +57,17 @@ const (
 	ruleOrigins exprType = "origins"
 	ruleHeaders exprType = "headers"
 	ruleMethods exprType = "methods"
+	ruleExposed exprType = "exposed headers"
 )
 
 type Rule struct {
-	o []string // origins
-	h []string // headers
-	m []string // methods
+	o      []string // origins
+	h      []string // headers
+	m      []string // methods
+	pn     bool     // allow private network
+	status int      // preflight response status, 0 - not set
+	pt     bool     // pass preflight through to the next handler
+	e      []string // exposed headers
 }
 
 func (r Rule) Origins() []string {
@@ -62,8 +82,36 @@ func (r Rule) Methods() []string {
 	return r.m
 }
 
+// ExposedHeaders returns the headers of actual responses exposed to scripts
+// (Access-Control-Expose-Headers).
+func (r Rule) ExposedHeaders() []string {
+	return r.e
+}
+
+// AllowPrivateNetwork reports whether the rule allows requests to private
+// network (Access-Control-Allow-Private-Network).
+func (r Rule) AllowPrivateNetwork() bool {
+	return r.pn
+}
+
+// PreflightStatus returns the status code of successful preflight responses.
+// It returns 0 when the rule does not set the status.
+func (r Rule) PreflightStatus() int {
+	return r.status
+}
+
+// PreflightPassthrough reports whether successful preflight requests are
+// passed to the next handler after CORS headers are set.
+func (r Rule) PreflightPassthrough() bool {
+	return r.pt
+}
+
 type RuleBuilder struct {
-	expr map[exprType][]string
+	expr   map[exprType][]string
+	rm     []string // methods as given, BuildE reports invalid ones
+	pn     bool
+	status int
+	pt     bool
 }
 
 func NewRuleBuilder() RuleBuilder {
@@ -90,7 +138,10 @@ func (b RuleBuilder) WithHeaders(h ...string) RuleBuilder {
 	return b
 }
 
+// WithMethods sets allowed methods. Invalid methods are ignored by Build and
+// reported by BuildE.
 func (b RuleBuilder) WithMethods(m ...string) RuleBuilder {
+	b.rm = m
 	vm := filterMethods(m)
 	if len(vm) > 0 {
 		if b.expr == nil {
@@ -101,8 +152,38 @@ func (b RuleBuilder) WithMethods(m ...string) RuleBuilder {
 	return b
 }
 
+// WithExposedHeaders sets the headers of actual responses exposed to scripts.
+func (b RuleBuilder) WithExposedHeaders(h ...string) RuleBuilder {
+	if len(h) > 0 {
+		if b.expr == nil {
+			b.expr = make(map[exprType][]string)
+		}
+		b.expr[ruleExposed] = h
+	}
+	return b
+}
+
+func (b RuleBuilder) WithPrivateNetwork() RuleBuilder {
+	b.pn = true
+	return b
+}
+
+// WithPreflightStatus sets the status code of successful preflight responses.
+// Codes out of 2xx range are ignored.
+func (b RuleBuilder) WithPreflightStatus(code int) RuleBuilder {
+	if isPreflightStatus(code) {
+		b.status = code
+	}
+	return b
+}
+
+func (b RuleBuilder) WithPreflightPassthrough() RuleBuilder {
+	b.pt = true
+	return b
+}
+
 func (b RuleBuilder) Build() Rule {
-	r := Rule{}
+	r := Rule{pn: b.pn, status: b.status, pt: b.pt}
 	for k, v := range b.expr {
 		switch k {
 		case ruleOrigins:
@@ -111,23 +192,83 @@ func (b RuleBuilder) Build() Rule {
 			r.h = v
 		case ruleMethods:
 			r.m = v
+		case ruleExposed:
+			r.e = v
 		}
 	}
 	return r
 }
 
+// BuildE builds the rule and reports invalid values with the checks of
+// Rules.ParseStrict: origins must be *, null or scheme://host[:port] in lower
+// case, headers must be field names and methods must be valid HTTP methods.
+// Methods are normalized as in config: they are upper-cased and * alone
+// allows all methods.
+func (b RuleBuilder) BuildE() (Rule, error) {
+	methods := normalizeMethods(b.rm)
+	if err := validateRule(b.expr[ruleOrigins], b.expr[ruleHeaders], methods, b.expr[ruleExposed]); err != nil {
+		return Rule{}, fmt.Errorf("invalid cors rule: %w", err)
+	}
+
+	r := b.Build()
+	if len(methods) > 0 {
+		r.m = methods
+	}
+	return r, nil
+}
+
 type Rules struct {
-	raw string
-	op  []string // ordered paths list
-	pr  map[string]Rule
+	raw    string
+	format Format
+	op     []string // ordered paths list
+	pr     map[string]Rule
+	built  bool // created by RulesBuilder
 }
 
 func NewRules(config string) *Rules {
 	return &Rules{raw: config}
 }
 
+// NewRulesWithFormat creates rules of the config in the format.
+func NewRulesWithFormat(config string, f Format) *Rules {
+	return &Rules{raw: config, format: f}
+}
+
+// Parse parses the config. Rules created by RulesBuilder have no config and
+// are not parsed.
 func (r *Rules) Parse() error {
-	return r.parseTxt()
+	return r.parse(false)
+}
+
+// ParseStrict parses the config and additionally rejects malformed values
+// the browsers never match: origins must be *, null or scheme://host[:port]
+// in lower case and headers must be field names.
+func (r *Rules) ParseStrict() error {
+	return r.parse(true)
+}
+
+func (r *Rules) parse(strict bool) error {
+	if r.built {
+		return nil
+	}
+
+	switch r.format {
+	case FormatTxt, "":
+		return r.parseTxt(strict)
+	case FormatJSON, FormatYAML:
+		return r.parseStructured(strict)
+	default:
+		return fmt.Errorf("%s: unsupported format %s", parseErr, r.format)
+	}
+}
+
+// rulePath returns the path of the rule of the path, * when the wildcard rule
+// applies.
+func (r *Rules) rulePath(path string) string {
+	if _, ok := r.pr[path]; ok {
+		return path
+	}
+	return wildcard
 }
 
 func (r *Rules) Paths() []string {
@@ -146,24 +287,31 @@ func (r *Rules) OfPath(path string) (Rule, bool) {
 	return Rule{}, false
 }
 
-func (r *Rules) parseTxt() error {
-	if strings.TrimSpace(r.raw) == "" {
-		return fmt.Errorf("%s: cannot be empty", parseErr)
+func (r *Rules) parseTxt(strict bool) error {
+	sets, lines, err := parseSets(splitLines(r.raw))
+	if err != nil {
+		return err
 	}
 
-	rawRules := strings.Split(r.raw, rulesDlm)
+	return r.parseRules(sets, lines, strict)
+}
 
-	for i, rr := range rawRules {
-		rr = strings.TrimSpace(rr)
+// parseRules parses rules lines in txt format with set references. Strict
+// parsing validates the rules values.
+func (r *Rules) parseRules(sets *sets, lines []line, strict bool) error {
+	if len(lines) == 0 {
+		return fmt.Errorf("%s: cannot be empty", parseErr)
+	}
 
-		// skip empty rows in confing
-		if len(rr) == 0 {
-			continue
+	for _, l := range lines {
+		pohm, err := splitFields(l)
+		if err != nil {
+			return err
 		}
 
-		pohm := strings.Split(rr, fieldsDlm)
-		if s := len(pohm); s != fNum {
-			return fmt.Errorf("%s: invalid amount of fields in rule %d, got %d want %d", parseErr, i+1, s, fNum)
+		where := r.location(l)
+		if err := expandFields(sets, pohm, where); err != nil {
+			return err
 		}
 
 		paths := parsePaths(pohm[pIdx])
@@ -173,47 +321,212 @@ func (r *Rules) parseTxt() error {
 
 		origins := parseOrigins(pohm[oIdx])
 		headers := parseHeaders(pohm[hIdx])
-		methods, err := parseMethods(pohm[mIdx], i+1)
+		methods, err := parseMethods(pohm[mIdx], l.num)
 		if err != nil {
 			return err
 		}
 
+		rule := Rule{
+			o: origins,
+			h: headers,
+			m: methods,
+		}
+
+		if len(pohm) > xIdx {
+			if err := parseRuleOptions(sets, pohm[xIdx], l.num, where, &rule); err != nil {
+				return err
+			}
+		}
+
+		if strict {
+			if err := validateRule(origins, headers, methods, rule.e); err != nil {
+				return fmt.Errorf("%s: %v in rule %d", parseErr, err, l.num)
+			}
+		}
+
 		for _, p := range paths {
 			if p == "" {
 				return fmt.Errorf("%s: path cannot be empty", parseErr)
 			}
+		}
 
-			// ignore repeatable occurrences of path in config
-			if _, ok := r.pr[p]; ok {
-				continue
-			}
+		if r.add(paths, rule) {
+			// stop parsing when found path wildcard
+			return nil
+		}
+	}
 
-			if r.op == nil {
-				r.op = append([]string{}, p)
-			} else {
-				r.op = append(r.op, p)
-			}
+	return nil
+}
 
-			if r.pr == nil {
-				r.pr = make(map[string]Rule)
-			}
+// splitFields returns the fields of the rule line.
+func splitFields(l line) ([]string, error) {
+	pohm := strings.Split(l.s, fieldsDlm)
+	if s := len(pohm); s < fMin {
+		return nil, fmt.Errorf("%s: invalid amount of fields in rule %d, got %d want %d", parseErr, l.num, s, fMin)
+	} else if s > fNum {
+		return nil, fmt.Errorf("%s: invalid amount of fields in rule %d, got %d want %d", parseErr, l.num, s, fNum)
+	}
+	return pohm, nil
+}
 
-			r.pr[p] = Rule{
-				o: origins,
-				h: headers,
-				m: methods,
-			}
+// location describes the rule line in set errors: source lines of txt
+// config and rule numbers of structured configs.
+func (r *Rules) location(l line) string {
+	if r.format == FormatTxt || r.format == "" {
+		return fmt.Sprintf("line %d", l.num)
+	}
+	return fmt.Sprintf("rule %d", l.num)
+}
 
-			if p == wildcard {
-				// stop parsing when found path wildcard
-				return nil
-			}
+// expandFields replaces set references in origins, headers and methods fields.
+func expandFields(sets *sets, pohm []string, where string) error {
+	for _, idx := range []int{oIdx, hIdx, mIdx} {
+		v, err := sets.expand(pohm[idx], where, nil)
+		if err != nil {
+			return err
 		}
+		pohm[idx] = v
+	}
+	return nil
+}
+
+// parseRuleOptions parses the options of the rule and replaces set references
+// in exposed headers.
+func parseRuleOptions(sets *sets, s string, ruleNum int, where string, r *Rule) error {
+	if err := parseOptions(s, ruleNum, r); err != nil {
+		return err
+	}
+
+	if len(r.e) == 0 {
+		return nil
 	}
 
+	exposed, err := sets.expand(strings.Join(r.e, valuesDlm), where, nil)
+	if err != nil {
+		return err
+	}
+	r.e = strings.Split(exposed, valuesDlm)
 	return nil
 }
 
+// add sets the rule of the paths not having rules yet. It reports whether
+// the wildcard path is added, no more paths are added after it.
+func (r *Rules) add(paths []string, rule Rule) bool {
+	for _, p := range paths {
+		// ignore repeatable occurrences of path in config
+		if _, ok := r.pr[p]; ok {
+			continue
+		}
+
+		r.op = append(r.op, p)
+
+		if r.pr == nil {
+			r.pr = make(map[string]Rule)
+		}
+
+		r.pr[p] = rule
+
+		if p == wildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RulesBuilder builds rules of paths in Go without config. The rules have the
+// same semantics as parsed configs: the first rule added for a path applies
+// and paths added after the wildcard path are ignored.
+type RulesBuilder struct {
+	entries []rulesEntry
+}
+
+type rulesEntry struct {
+	paths []string
+	rule  Rule
+}
+
+func NewRulesBuilder() RulesBuilder {
+	return RulesBuilder{}
+}
+
+// Add adds the rule of the paths. Empty paths are ignored.
+func (b RulesBuilder) Add(rule Rule, paths ...string) RulesBuilder {
+	var pp []string
+	for _, p := range paths {
+		if p != "" {
+			pp = append(pp, p)
+		}
+	}
+
+	if len(pp) > 0 {
+		// copy entries to keep builders derived from b independent
+		b.entries = append(b.entries[:len(b.entries):len(b.entries)], rulesEntry{paths: pp, rule: rule})
+	}
+	return b
+}
+
+// Build returns the rules, they are ready to use without parsing.
+func (b RulesBuilder) Build() *Rules {
+	r := &Rules{built: true}
+	for _, e := range b.entries {
+		if r.add(e.paths, e.rule) {
+			break
+		}
+	}
+	return r
+}
+
+// line is a logical config line, num is the source line it starts at.
+type line struct {
+	num int
+	s   string
+}
+
+// splitLines strips comments and empty lines from the raw config and joins
+// lines ending with a backslash with the ones following them. Comment-only
+// and empty lines do not end continued lines.
+func splitLines(raw string) []line {
+	var (
+		ll   []line
+		cur  line
+		open bool
+	)
+
+	for i, s := range strings.Split(raw, rulesDlm) {
+		if idx := strings.Index(s, commentMark); idx >= 0 {
+			s = s[:idx]
+		}
+		s = strings.TrimSpace(s)
+
+		if open && s == "" {
+			continue
+		}
+
+		if !open {
+			cur = line{num: i + 1}
+		}
+
+		open = strings.HasSuffix(s, continuation)
+		if open {
+			s = strings.TrimSpace(strings.TrimSuffix(s, continuation))
+		}
+		cur.s += s
+
+		if !open && cur.s != "" {
+			ll = append(ll, cur)
+		}
+	}
+
+	// config ends with a continuation
+	if open && cur.s != "" {
+		ll = append(ll, cur)
+	}
+
+	return ll
+}
+
 func parsePaths(s string) []string {
 	var p []string
 	if s != "" {
@@ -245,11 +558,7 @@ func parseMethods(s string, ruleNum int) ([]string, error) {
 		return nil, nil
 	}
 
-	if s == wildcard {
-		return allMethods, nil
-	}
-
-	m := strings.Split(strings.ToUpper(s), valuesDlm)
+	m := normalizeMethods(strings.Split(s, valuesDlm))
 	for _, a := range m {
 		if ok := contains(validMethods, a); !ok {
 			return nil, fmt.Errorf("%s: invalid HTTP method %s in rule %d", parseErr, a, ruleNum)
@@ -259,6 +568,50 @@ func parseMethods(s string, ruleNum int) ([]string, error) {
 	return m, nil
 }
 
+func parseOptions(s string, ruleNum int, r *Rule) error {
+	s = strings.TrimSpace(s)
+
+	if s == "" {
+		return nil
+	}
+
+	for _, o := range strings.Split(s, valuesDlm) {
+		var v string
+		if idx := strings.Index(o, optValueDlm); idx >= 0 {
+			o, v = o[:idx], strings.TrimSpace(o[idx+1:])
+		}
+		o = strings.ToLower(strings.TrimSpace(o))
+
+		switch o {
+		case optPrivateNetwork:
+			r.pn = true
+		case optPassthrough:
+			r.pt = true
+		case optStatus:
+			code, err := strconv.Atoi(v)
+			if err != nil || !isPreflightStatus(code) {
+				return fmt.Errorf("%s: invalid preflight status %s in rule %d", parseErr, v, ruleNum)
+			}
+			r.status = code
+		case optExpose:
+			if v == "" {
+				return fmt.Errorf("%s: exposed header cannot be empty in rule %d", parseErr, ruleNum)
+			}
+			r.e = append(r.e, v)
+		default:
+			return fmt.Errorf("%s: invalid option %s in rule %d", parseErr, o, ruleNum)
+		}
+	}
+
+	return nil
+}
+
+// isPreflightStatus reports whether the code is a valid status of successful
+// preflight response.
+func isPreflightStatus(code int) bool {
+	return code >= http.StatusOK && code < http.StatusMultipleChoices
+}
+
 func contains(l []string, x string) bool {
 	for _, a := range l {
 		if a == x {
@@ -268,6 +621,19 @@ func contains(l []string, x string) bool {
 	return false
 }
 
+// normalizeMethods returns upper case methods, * alone stands for all methods.
+func normalizeMethods(mm []string) []string {
+	if len(mm) == 1 && strings.TrimSpace(mm[0]) == wildcard {
+		return allMethods
+	}
+
+	nm := make([]string, 0, len(mm))
+	for _, m := range mm {
+		nm = append(nm, strings.ToUpper(m))
+	}
+	return nm
+}
+
 func filterMethods(mm []string) []string {
 	fm := make([]string, 0, len(mm))
 	for _, m := range mm {
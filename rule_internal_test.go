@@ -28,11 +28,96 @@ func TestRuleParseError(t *testing.T) {
 			config: "*;;",
 			err:    "invalid cors rules: invalid amount of fields in rule 1, got 3 want 4",
 		},
+		{
+			desc:   "fails when cors rules config has too many fields in a rule",
+			config: "*;;;;;",
+			err:    "invalid cors rules: invalid amount of fields in rule 1, got 6 want 5",
+		},
+		{
+			desc:   "fails when cors rules config has invalid preflight status",
+			config: "*;;;;status=foo",
+			err:    "invalid cors rules: invalid preflight status foo in rule 1",
+		},
+		{
+			desc:   "fails when cors rules config has not successful preflight status",
+			config: "*;;;;status=301",
+			err:    "invalid cors rules: invalid preflight status 301 in rule 1",
+		},
+		{
+			desc:   "fails when cors rules config has invalid option",
+			config: "*;;;;foo",
+			err:    "invalid cors rules: invalid option foo in rule 1",
+		},
 		{
 			desc:   "fails when cors rules config has invalid http method",
 			config: "*;;;foo",
 			err:    "invalid cors rules: invalid HTTP method FOO in rule 1",
 		},
+		{
+			desc:   "fails when cors rules config has only comments",
+			config: "# foo\n  # bar",
+			err:    "invalid cors rules: cannot be empty",
+		},
+		{
+			desc:   "reports source line of the rule after comments",
+			config: "# foo\n/a;;;\n\n/b;;;bar # baz",
+			err:    "invalid cors rules: invalid HTTP method BAR in rule 4",
+		},
+		{
+			desc:   "reports source line where continued rule starts",
+			config: "/a;;;\n/b;foo.com,\\\n  bar.com;;\\\n  PUT,foo",
+			err:    "invalid cors rules: invalid HTTP method FOO in rule 2",
+		},
+		{
+			desc:   "fails when set definition is invalid",
+			config: "@foo\n*;;;",
+			err:    "invalid cors rules: invalid set definition in line 1",
+		},
+		{
+			desc:   "fails when set name is empty",
+			config: "@ = foo.com\n*;;;",
+			err:    "invalid cors rules: set name cannot be empty in line 1",
+		},
+		{
+			desc:   "fails when set is redefined",
+			config: "@foo = foo.com\n@foo = bar.com\n*;;;",
+			err:    "invalid cors rules: set @foo redefined in line 2",
+		},
+		{
+			desc:   "fails when rule references undefined set",
+			config: "@foo = foo.com\n*;@bar;;",
+			err:    "invalid cors rules: undefined set @bar in line 2",
+		},
+		{
+			desc:   "fails when rule references undefined set after space",
+			config: "@foo = foo.com\n*;foo.com, @bar;;",
+			err:    "invalid cors rules: undefined set @bar in line 2",
+		},
+		{
+			desc:   "fails when exposed header references undefined set",
+			config: "@foo = foo.com\n*;;;;expose=@bar",
+			err:    "invalid cors rules: undefined set @bar in line 2",
+		},
+		{
+			desc:   "fails when set references undefined set",
+			config: "@foo = foo.com,@bar\n*;@foo;;",
+			err:    "invalid cors rules: undefined set @bar in line 1",
+		},
+		{
+			desc:   "fails when sets have cyclic references",
+			config: "@foo = foo.com,@bar\n@bar = @baz\n@baz = @foo\n*;;;",
+			err:    "invalid cors rules: cyclic reference to set @foo in line 1",
+		},
+		{
+			desc:   "fails when exposed header is empty",
+			config: "*;;;;expose=",
+			err:    "invalid cors rules: exposed header cannot be empty in rule 1",
+		},
+		{
+			desc:   "fails when config has only sets",
+			config: "@foo = foo.com",
+			err:    "invalid cors rules: cannot be empty",
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
@@ -117,6 +202,37 @@ func TestRuleParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:   "parses rule options",
+			config: "*;;;; Private-Network ,passthrough,status = 204",
+			r: &Rules{
+				raw: "*;;;; Private-Network ,passthrough,status = 204",
+				op:  []string{"*"},
+				pr: map[string]Rule{
+					"*": {
+						o:      nil,
+						h:      nil,
+						m:      nil,
+						pn:     true,
+						status: http.StatusNoContent,
+						pt:     true,
+					},
+				},
+			},
+		},
+		{
+			desc:   "parses exposed headers options",
+			config: "*;;;;expose=X-Request-Id, expose = Grpc-Status",
+			r: &Rules{
+				raw: "*;;;;expose=X-Request-Id, expose = Grpc-Status",
+				op:  []string{"*"},
+				pr: map[string]Rule{
+					"*": {
+						e: []string{"X-Request-Id", "Grpc-Status"},
+					},
+				},
+			},
+		},
 		{
 			desc: "parses multiline config",
 			config: `/a;foo.com;content-type;DELETE
@@ -182,6 +298,124 @@ func TestRuleParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "ignores comments",
+			config: `# frontends
+			/a;foo.com;content-type;DELETE # legacy client
+			#/b;bar.com;content-length;PUT`,
+			r: &Rules{
+				raw: `# frontends
+			/a;foo.com;content-type;DELETE # legacy client
+			#/b;bar.com;content-length;PUT`,
+				op: []string{"/a"},
+				pr: map[string]Rule{
+					"/a": {
+						o: []string{"foo.com"},
+						h: []string{"content-type"},
+						m: []string{http.MethodDelete},
+					},
+				},
+			},
+		},
+		{
+			desc: "joins continued lines",
+			config: `/a;foo.com,\
+			bar.com; \
+			content-type;DELETE`,
+			r: &Rules{
+				raw: `/a;foo.com,\
+			bar.com; \
+			content-type;DELETE`,
+				op: []string{"/a"},
+				pr: map[string]Rule{
+					"/a": {
+						o: []string{"foo.com", "bar.com"},
+						h: []string{"content-type"},
+						m: []string{http.MethodDelete},
+					},
+				},
+			},
+		},
+		{
+			desc: "joins continued lines around comments and empty lines",
+			config: `/a;https://a.com,\
+				# partner
+				https://b.com,\
+
+				https://c.com;;GET`,
+			r: &Rules{
+				raw: `/a;https://a.com,\
+				# partner
+				https://b.com,\
+
+				https://c.com;;GET`,
+				op: []string{"/a"},
+				pr: map[string]Rule{
+					"/a": {
+						o: []string{"https://a.com", "https://b.com", "https://c.com"},
+						m: []string{http.MethodGet},
+					},
+				},
+			},
+		},
+		{
+			desc: "expands sets",
+			config: `@frontends = https://a.com,https://b.com
+			@internal = @frontends,https://c.com
+			@write = PUT,delete
+			/a;@internal;content-type;GET,@write
+			/b;@frontends,https://d.com;;`,
+			r: &Rules{
+				raw: `@frontends = https://a.com,https://b.com
+			@internal = @frontends,https://c.com
+			@write = PUT,delete
+			/a;@internal;content-type;GET,@write
+			/b;@frontends,https://d.com;;`,
+				op: []string{"/a", "/b"},
+				pr: map[string]Rule{
+					"/a": {
+						o: []string{"https://a.com", "https://b.com", "https://c.com"},
+						h: []string{"content-type"},
+						m: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+					},
+					"/b": {
+						o: []string{"https://a.com", "https://b.com", "https://d.com"},
+						h: nil,
+						m: nil,
+					},
+				},
+			},
+		},
+		{
+			desc: "expands sets in exposed headers options",
+			config: `@grpc = Grpc-Status,Grpc-Message
+			/a;;;;expose=X-Request-Id,expose=@grpc`,
+			r: &Rules{
+				raw: `@grpc = Grpc-Status,Grpc-Message
+			/a;;;;expose=X-Request-Id,expose=@grpc`,
+				op: []string{"/a"},
+				pr: map[string]Rule{
+					"/a": {
+						e: []string{"X-Request-Id", "Grpc-Status", "Grpc-Message"},
+					},
+				},
+			},
+		},
+		{
+			desc: "expands sets referenced after spaces",
+			config: `@f = https://a.com,https://b.com
+			/a;https://c.com, @f ;;`,
+			r: &Rules{
+				raw: `@f = https://a.com,https://b.com
+			/a;https://c.com, @f ;;`,
+				op: []string{"/a"},
+				pr: map[string]Rule{
+					"/a": {
+						o: []string{"https://c.com", "https://a.com", "https://b.com"},
+					},
+				},
+			},
+		},
 		{
 			desc: "stops parsing when found paths wildcard",
 			config: `/a;foo.com;content-type;DELETE
@@ -2,6 +2,7 @@ package cors_test
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/antklim/cors"
@@ -122,6 +123,7 @@ func TestRuleBuilder(t *testing.T) {
 				assert.Equal(t, []string{"a", "b"}, r.Origins())
 				assert.Equal(t, []string{"content-type"}, r.Headers())
 				assert.Equal(t, []string{http.MethodDelete, http.MethodPatch}, r.Methods())
+				assert.False(t, r.AllowPrivateNetwork())
 			},
 		},
 	}
@@ -136,3 +138,262 @@ func TestRuleBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleBuilderOptions(t *testing.T) {
+	rule := cors.NewRuleBuilder().Build()
+	assert.False(t, rule.AllowPrivateNetwork())
+	assert.Zero(t, rule.PreflightStatus())
+	assert.False(t, rule.PreflightPassthrough())
+
+	rule = cors.NewRuleBuilder().
+		WithPrivateNetwork().
+		WithPreflightStatus(http.StatusNoContent).
+		WithPreflightPassthrough().
+		Build()
+	assert.True(t, rule.AllowPrivateNetwork())
+	assert.Equal(t, http.StatusNoContent, rule.PreflightStatus())
+	assert.True(t, rule.PreflightPassthrough())
+
+	rule = cors.NewRuleBuilder().WithPreflightStatus(http.StatusNotFound).Build()
+	assert.Zero(t, rule.PreflightStatus())
+}
+
+func TestRulesBuilder(t *testing.T) {
+	a := cors.NewRuleBuilder().WithOrigins("foo.com").WithMethods(http.MethodPut).Build()
+	b := cors.NewRuleBuilder().WithOrigins("bar.com").WithMethods(http.MethodPatch).WithPrivateNetwork().Build()
+	wild := cors.NewRuleBuilder().WithMethods(http.MethodGet).Build()
+
+	testCases := []struct {
+		desc    string
+		builder cors.RulesBuilder
+		paths   []string
+		rules   map[string]cors.Rule
+	}{
+		{
+			desc:    "has no paths when no rules added",
+			builder: cors.NewRulesBuilder(),
+			rules:   map[string]cors.Rule{"/a": {}},
+		},
+		{
+			desc:    "applies the first rule of path",
+			builder: cors.NewRulesBuilder().Add(a, "/a", "/b").Add(b, "/b", "/c"),
+			paths:   []string{"/a", "/b", "/c"},
+			rules:   map[string]cors.Rule{"/a": a, "/b": a, "/c": b, "/d": {}},
+		},
+		{
+			desc:    "ignores empty paths",
+			builder: cors.NewRulesBuilder().Add(a, "", "/a").Add(b, ""),
+			paths:   []string{"/a"},
+			rules:   map[string]cors.Rule{"/a": a},
+		},
+		{
+			desc:    "ignores paths after wildcard",
+			builder: cors.NewRulesBuilder().Add(a, "/a").Add(wild, "*", "/b").Add(b, "/c"),
+			paths:   []string{"/a", "*"},
+			rules:   map[string]cors.Rule{"/a": a, "/b": wild, "/c": wild},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rules := tC.builder.Build()
+			require.NoError(t, rules.Parse())
+			assert.Equal(t, tC.paths, rules.Paths())
+			for p, want := range tC.rules {
+				rule, _ := rules.OfPath(p)
+				assert.Equal(t, want, rule, p)
+			}
+		})
+	}
+}
+
+func TestRulesBuilderIsEquivalentToConfig(t *testing.T) {
+	config := "/a,/b;foo.com;content-type;PUT;private-network\n/b,/c;bar.com;;PATCH;status=204\n*;*;;GET"
+	parsed := cors.NewRules(config)
+	require.NoError(t, parsed.Parse())
+
+	built := cors.NewRulesBuilder().
+		Add(cors.NewRuleBuilder().
+			WithOrigins("foo.com").
+			WithHeaders("content-type").
+			WithMethods(http.MethodPut).
+			WithPrivateNetwork().
+			Build(), "/a", "/b").
+		Add(cors.NewRuleBuilder().
+			WithOrigins("bar.com").
+			WithMethods(http.MethodPatch).
+			WithPreflightStatus(http.StatusNoContent).
+			Build(), "/b", "/c").
+		Add(cors.NewRuleBuilder().WithOrigins("*").WithMethods(http.MethodGet).Build(), "*").
+		Build()
+
+	want, err := parsed.Encode(cors.FormatTxt)
+	require.NoError(t, err)
+	got, err := built.Encode(cors.FormatTxt)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Empty(t, cors.Diff(parsed, built))
+}
+
+func TestRulesBuilderIsImmutable(t *testing.T) {
+	rule := cors.NewRuleBuilder().WithMethods(http.MethodPut).Build()
+	base := cors.NewRulesBuilder().Add(rule, "/a")
+	b1 := base.Add(rule, "/b")
+	b2 := base.Add(rule, "/c")
+
+	assert.Equal(t, []string{"/a"}, base.Build().Paths())
+	assert.Equal(t, []string{"/a", "/b"}, b1.Build().Paths())
+	assert.Equal(t, []string{"/a", "/c"}, b2.Build().Paths())
+}
+
+func TestRuleBuilderBuildE(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		builder cors.RuleBuilder
+		methods []string
+		err     string
+	}{
+		{
+			desc: "builds valid rule",
+			builder: cors.NewRuleBuilder().
+				WithOrigins("https://foo.bar.org", "http://localhost:8080", "null").
+				WithHeaders("Content-Type", "x-correlation-id").
+				WithMethods(http.MethodGet, http.MethodPut),
+		},
+		{
+			desc:    "builds rule allowing any origin",
+			builder: cors.NewRuleBuilder().WithOrigins("*"),
+		},
+		{
+			desc:    "builds rule with lower case methods",
+			builder: cors.NewRuleBuilder().WithMethods("get", "Put"),
+			methods: []string{http.MethodGet, http.MethodPut},
+		},
+		{
+			desc:    "builds rule with wildcard methods",
+			builder: cors.NewRuleBuilder().WithMethods("*"),
+			methods: []string{
+				http.MethodDelete, http.MethodGet, http.MethodHead,
+				http.MethodPatch, http.MethodPost, http.MethodPut,
+			},
+		},
+		{
+			desc:    "fails with invalid method",
+			builder: cors.NewRuleBuilder().WithMethods(http.MethodGet, "get", "FOO"),
+			err:     "invalid cors rule: invalid HTTP method FOO",
+		},
+		{
+			desc:    "fails with wildcard among methods",
+			builder: cors.NewRuleBuilder().WithMethods(http.MethodGet, "*"),
+			err:     "invalid cors rule: invalid HTTP method *",
+		},
+		{
+			desc:    "fails with origin without scheme",
+			builder: cors.NewRuleBuilder().WithOrigins("foo.bar.org"),
+			err:     `invalid cors rule: invalid origin "foo.bar.org"`,
+		},
+		{
+			desc:    "fails with origin with path",
+			builder: cors.NewRuleBuilder().WithOrigins("https://foo.bar.org/"),
+			err:     `invalid cors rule: invalid origin "https://foo.bar.org/"`,
+		},
+		{
+			desc:    "fails with upper case origin",
+			builder: cors.NewRuleBuilder().WithOrigins("https://Foo.bar.org"),
+			err:     `invalid cors rule: invalid origin "https://Foo.bar.org"`,
+		},
+		{
+			desc:    "fails with invalid header name",
+			builder: cors.NewRuleBuilder().WithHeaders("content type"),
+			err:     `invalid cors rule: invalid header "content type"`,
+		},
+		{
+			desc:    "fails with wildcard header",
+			builder: cors.NewRuleBuilder().WithHeaders("*"),
+			err:     `invalid cors rule: invalid header "*"`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rule, err := tC.builder.BuildE()
+			if tC.err != "" {
+				assert.EqualError(t, err, tC.err)
+				return
+			}
+			require.NoError(t, err)
+			if tC.methods != nil {
+				assert.Equal(t, tC.methods, rule.Methods())
+				return
+			}
+			assert.Equal(t, tC.builder.Build(), rule)
+		})
+	}
+}
+
+func TestRulesParseStrict(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config string
+		format cors.Format
+		err    string
+	}{
+		{
+			desc:   "parses valid config",
+			config: "@origins = https://foo.bar.org,null\n/a;@origins;content-type;GET\n*;*;;GET",
+		},
+		{
+			desc:   "fails with invalid origin",
+			config: "/a;https://foo.bar.org;content-type;GET\n/b;foo.bar.org;;GET",
+			err:    `invalid cors rules: invalid origin "foo.bar.org" in rule 2`,
+		},
+		{
+			desc:   "fails with invalid origin in set",
+			config: "@origins = https://foo.bar.org/\n/a;@origins;;GET",
+			err:    `invalid cors rules: invalid origin "https://foo.bar.org/" in rule 2`,
+		},
+		{
+			desc:   "fails with invalid header",
+			config: "/a;*;content-type,x(id);GET",
+			err:    `invalid cors rules: invalid header "x(id)" in rule 1`,
+		},
+		{
+			desc:   "fails with invalid origin in yaml config",
+			config: "rules:\n  - paths: [/a]\n    origins: [FOO.com]\n",
+			format: cors.FormatYAML,
+			err:    `invalid cors rules: invalid origin "FOO.com" in rule 1`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := cors.NewRulesWithFormat(tC.config, tC.format).ParseStrict()
+			if tC.err != "" {
+				assert.EqualError(t, err, tC.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRulesParseIsNotStrict(t *testing.T) {
+	err := cors.NewRules("/a;foo.bar.org;content type;GET").Parse()
+	assert.NoError(t, err)
+
+	rules := cors.NewRules("/a;foo.bar.org;content type;GET")
+	assert.Error(t, rules.ParseStrict())
+	assert.NoError(t, rules.Parse())
+}
+
+func TestRuleBuilderBuildEMatchesParseStrict(t *testing.T) {
+	for _, methods := range []string{"get", "*", "Get,put"} {
+		rules := cors.NewRules("/a;https://a.org;;" + methods)
+		require.NoError(t, rules.ParseStrict(), methods)
+		parsed, _ := rules.OfPath("/a")
+
+		built, err := cors.NewRuleBuilder().
+			WithOrigins("https://a.org").
+			WithMethods(strings.Split(methods, ",")...).
+			BuildE()
+		require.NoError(t, err, methods)
+		assert.Equal(t, parsed, built, methods)
+	}
+}
@@ -0,0 +1,134 @@
+package cors
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	setMark string = "@"
+	setDlm  string = "="
+)
+
+// sets holds named values lists defined in config as @name = valueA,valueB.
+// A set can reference other sets. Definitions of txt config keep source line
+// numbers for errors, definitions of structured configs do not have them.
+type sets struct {
+	raw      map[string]line   // set name to its definition
+	resolved map[string]string // set name to its expanded values
+}
+
+func newSets() *sets {
+	return &sets{
+		raw:      make(map[string]line),
+		resolved: make(map[string]string),
+	}
+}
+
+// parseSets separates set definitions from rules and resolves all sets.
+func parseSets(lines []line) (*sets, []line, error) {
+	s := newSets()
+
+	var (
+		rules []line
+		names []string // set names in order of definition
+	)
+	for _, l := range lines {
+		if !strings.HasPrefix(l.s, setMark) {
+			rules = append(rules, l)
+			continue
+		}
+
+		def := strings.TrimPrefix(l.s, setMark)
+		idx := strings.Index(def, setDlm)
+		if idx < 0 {
+			return nil, nil, fmt.Errorf("%s: invalid set definition in line %d", parseErr, l.num)
+		}
+
+		name := strings.TrimSpace(def[:idx])
+		if name == "" {
+			return nil, nil, fmt.Errorf("%s: set name cannot be empty in line %d", parseErr, l.num)
+		}
+
+		if _, ok := s.raw[name]; ok {
+			return nil, nil, fmt.Errorf("%s: set %s%s redefined in line %d", parseErr, setMark, name, l.num)
+		}
+
+		s.raw[name] = line{num: l.num, s: strings.TrimSpace(def[idx+1:])}
+		names = append(names, name)
+	}
+
+	if err := s.resolveAll(names); err != nil {
+		return nil, nil, err
+	}
+
+	return s, rules, nil
+}
+
+// resolveAll resolves the sets in the order of names.
+func (s *sets) resolveAll(names []string) error {
+	for _, name := range names {
+		if _, err := s.resolve(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the expanded values of the set. Stack holds the names of
+// the sets being resolved to detect cyclic references.
+func (s *sets) resolve(name string, stack []string) (string, error) {
+	if v, ok := s.resolved[name]; ok {
+		return v, nil
+	}
+
+	def := s.raw[name]
+	if contains(stack, name) {
+		if def.num > 0 {
+			return "", fmt.Errorf("%s: cyclic reference to set %s%s in line %d", parseErr, setMark, name, def.num)
+		}
+		return "", fmt.Errorf("%s: cyclic reference to set %s%s", parseErr, setMark, name)
+	}
+
+	where := "set " + setMark + name
+	if def.num > 0 {
+		where = fmt.Sprintf("line %d", def.num)
+	}
+
+	v, err := s.expand(def.s, where, append(stack, name))
+	if err != nil {
+		return "", err
+	}
+
+	s.resolved[name] = v
+	return v, nil
+}
+
+// expand replaces set references in the values list with the values of the
+// referenced sets. Where describes the location of the values list in errors.
+func (s *sets) expand(values, where string, stack []string) (string, error) {
+	if !strings.Contains(values, setMark) {
+		return values, nil
+	}
+
+	vv := strings.Split(values, valuesDlm)
+	for i, v := range vv {
+		v = strings.TrimSpace(v)
+		if !strings.HasPrefix(v, setMark) {
+			continue
+		}
+
+		name := strings.TrimPrefix(v, setMark)
+		if _, ok := s.raw[name]; !ok {
+			return "", fmt.Errorf("%s: undefined set %s%s in %s", parseErr, setMark, name, where)
+		}
+
+		ev, err := s.resolve(name, stack)
+		if err != nil {
+			return "", err
+		}
+		vv[i] = ev
+	}
+
+	return strings.Join(vv, valuesDlm), nil
+}
@@ -0,0 +1,37 @@
+package cors
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogObserver returns the observer logging CORS decisions with the logger.
+// Denied requests are logged with warning level, allowed requests with info
+// level and skipped requests with debug level.
+func SlogObserver(l *slog.Logger) Observer {
+	return ObserverFunc(func(d Decision) {
+		level := slog.LevelInfo
+		switch d.Outcome {
+		case OutcomeDenied:
+			level = slog.LevelWarn
+		case OutcomeSkipped:
+			level = slog.LevelDebug
+		case OutcomeAllowed:
+		}
+
+		l.LogAttrs(context.Background(), level, "cors decision",
+			slog.String("path", d.Path),
+			slog.Bool("preflight", d.Preflight),
+			slog.String("origin", d.Origin),
+			slog.String("method", d.Method),
+			slog.Any("headers", d.Headers),
+			slog.String("outcome", string(d.Outcome)),
+			slog.String("reason", string(d.Reason)),
+			slog.Group("rule",
+				slog.Any("origins", d.Rule.Origins()),
+				slog.Any("headers", d.Rule.Headers()),
+				slog.Any("methods", d.Rule.Methods()),
+			),
+		)
+	})
+}
@@ -0,0 +1,44 @@
+package cors_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	rule := cors.NewRuleBuilder().WithOrigins("https://foo.bar.org").WithMethods(http.MethodPut).Build()
+	h := cors.Middleware("/a", rule, cors.DecisionObserver(cors.SlogObserver(logger)))(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodOptions, "/a", nil)
+	req.Header.Set("Origin", "https://foo.bar.org")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	assert.Equal(t, "WARN", got["level"])
+	assert.Equal(t, "cors decision", got["msg"])
+	assert.Equal(t, "/a", got["path"])
+	assert.Equal(t, true, got["preflight"])
+	assert.Equal(t, "https://foo.bar.org", got["origin"])
+	assert.Equal(t, "DELETE", got["method"])
+	assert.Equal(t, "denied", got["outcome"])
+	assert.Equal(t, "method not allowed", got["reason"])
+	assert.Equal(t, map[string]interface{}{
+		"origins": []interface{}{"https://foo.bar.org"},
+		"headers": nil,
+		"methods": []interface{}{"PUT"},
+	}, got["rule"])
+}
@@ -0,0 +1,90 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Errors of origins never matching the Origin header sent by browsers.
+var (
+	ErrOriginFormat = errors.New("want scheme://host[:port]")
+	ErrOriginCase   = errors.New("browsers send lower case origins")
+)
+
+// validateRule checks that the rule values are well-formed: origins are
+// serialized origins (scheme://host[:port]), * or null, headers are field
+// names and methods are known HTTP methods.
+func validateRule(origins, headers, methods, exposed []string) error {
+	for _, o := range origins {
+		if ValidateOrigin(o) != nil {
+			return fmt.Errorf("invalid origin %q", o)
+		}
+	}
+
+	for _, h := range headers {
+		if !isValidHeader(h) {
+			return fmt.Errorf("invalid header %q", h)
+		}
+	}
+
+	for _, h := range exposed {
+		if !isValidHeader(h) {
+			return fmt.Errorf("invalid exposed header %q", h)
+		}
+	}
+
+	for _, m := range methods {
+		if !contains(validMethods, m) {
+			return fmt.Errorf("invalid HTTP method %s", m)
+		}
+	}
+
+	return nil
+}
+
+// ValidateOrigin checks that the origin can match the Origin header sent by
+// browsers: it is *, null or a lower case serialized origin. The error wraps
+// ErrOriginFormat, ErrOriginCase or both.
+func ValidateOrigin(o string) error {
+	if o == wildcard || o == "null" {
+		return nil
+	}
+
+	var errs []error
+	u, err := url.Parse(o)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Opaque != "" ||
+		u.Path != "" || u.ForceQuery || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		errs = append(errs, ErrOriginFormat)
+	}
+
+	if o != strings.ToLower(o) {
+		errs = append(errs, ErrOriginCase)
+	}
+
+	return errors.Join(errs...)
+}
+
+// isValidHeader reports whether the header is a field name token. The
+// wildcard is not supported, headers must be explicit.
+func isValidHeader(h string) bool {
+	if h == "" || h == wildcard {
+		return false
+	}
+
+	for _, c := range h {
+		if !isTokenChar(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isTokenChar(c rune) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
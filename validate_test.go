@@ -0,0 +1,35 @@
+package cors_test
+
+import (
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOrigin(t *testing.T) {
+	testCases := []struct {
+		origin string
+		errs   []error
+	}{
+		{origin: "*"},
+		{origin: "null"},
+		{origin: "https://foo.bar.org"},
+		{origin: "http://localhost:8080"},
+		{origin: "foo.bar.org", errs: []error{cors.ErrOriginFormat}},
+		{origin: "https://foo.bar.org/", errs: []error{cors.ErrOriginFormat}},
+		{origin: "https://Foo.bar.org", errs: []error{cors.ErrOriginCase}},
+		{origin: "https://Foo.bar.org/a", errs: []error{cors.ErrOriginFormat, cors.ErrOriginCase}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.origin, func(t *testing.T) {
+			err := cors.ValidateOrigin(tC.origin)
+			if len(tC.errs) == 0 {
+				assert.NoError(t, err)
+			}
+			for _, e := range tC.errs {
+				assert.ErrorIs(t, err, e)
+			}
+		})
+	}
+}
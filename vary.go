@@ -0,0 +1,149 @@
+package cors
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// varyWriter merges CORS values into the Vary header when the response
+// header is written, keeping the values set by the next handler.
+type varyWriter struct {
+	http.ResponseWriter
+	vary  []string
+	wrote bool
+}
+
+// writer returns the writer passed to the next handler. The original writer is
+// returned when there are no Vary values, otherwise varyWriter keeps the
+// optional interfaces of HTTP/1 and HTTP/2 writers.
+func (w *varyWriter) writer() http.ResponseWriter {
+	if len(w.vary) == 0 {
+		return w.ResponseWriter
+	}
+
+	_, hj := w.ResponseWriter.(http.Hijacker)
+	_, rf := w.ResponseWriter.(io.ReaderFrom)
+	_, p := w.ResponseWriter.(http.Pusher)
+	switch {
+	case hj && rf:
+		return &varyHTTP1Writer{w}
+	case hj:
+		return &varyHijackWriter{w}
+	case p:
+		return &varyPushWriter{w}
+	default:
+		return w
+	}
+}
+
+func (w *varyWriter) WriteHeader(code int) {
+	w.merge()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *varyWriter) Write(b []byte) (int, error) {
+	w.merge()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *varyWriter) Flush() {
+	w.merge()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original writer, it is used by http.ResponseController.
+func (w *varyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// varyHijackWriter is varyWriter of the writer supporting hijacking.
+type varyHijackWriter struct {
+	*varyWriter
+}
+
+func (w *varyHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// varyHTTP1Writer is varyWriter of the HTTP/1 writer.
+type varyHTTP1Writer struct {
+	*varyWriter
+}
+
+func (w *varyHTTP1Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *varyHTTP1Writer) ReadFrom(r io.Reader) (int64, error) {
+	w.merge()
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+// varyPushWriter is varyWriter of the HTTP/2 writer.
+type varyPushWriter struct {
+	*varyWriter
+}
+
+func (w *varyPushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// merge adds CORS values to the Vary header once, before the header is written.
+func (w *varyWriter) merge() {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	addVary(w.Header(), w.vary...)
+}
+
+// addVary adds values missing in the Vary header of h.
+func addVary(h http.Header, values ...string) {
+	var present []string
+	for _, v := range h.Values(varyHeader) {
+		for _, vv := range strings.Split(v, valuesDlm) {
+			vv = strings.TrimSpace(vv)
+			if vv == wildcard {
+				return
+			}
+			present = append(present, http.CanonicalHeaderKey(vv))
+		}
+	}
+
+	var missing []string
+	for _, v := range values {
+		if cv := http.CanonicalHeaderKey(v); !contains(present, cv) && !contains(missing, cv) {
+			missing = append(missing, cv)
+		}
+	}
+
+	if len(missing) > 0 {
+		h.Add(varyHeader, strings.Join(missing, ", "))
+	}
+}
+
+// MergeVary returns the Vary header value with the values missing in it, it
+// is used by adapters of servers with single value headers. Values may be
+// comma separated lists, they are not added when the value is *.
+func MergeVary(value string, values ...string) string {
+	h := make(http.Header)
+	if value != "" {
+		h.Set(varyHeader, value)
+	}
+
+	var vv []string
+	for _, v := range values {
+		for _, x := range strings.Split(v, valuesDlm) {
+			if x = strings.TrimSpace(x); x != "" {
+				vv = append(vv, x)
+			}
+		}
+	}
+	addVary(h, vv...)
+	return strings.Join(h.Values(varyHeader), ", ")
+}
@@ -0,0 +1,424 @@
+package cors_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antklim/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVary(t *testing.T) {
+	listed := cors.NewRuleBuilder().
+		WithOrigins("https://foo.bar.org", "https://bar.foo.org").
+		WithMethods(http.MethodPut).
+		Build()
+	anyOrigin := cors.NewRuleBuilder().WithOrigins("*").WithMethods(http.MethodPut).Build()
+
+	testCases := []struct {
+		desc    string
+		rule    cors.Rule
+		method  string
+		headers map[string]string
+		next    http.HandlerFunc
+		vary    []string
+	}{
+		{
+			desc:   "actual request varies by origin when origins listed",
+			rule:   listed,
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+			vary: []string{"Origin"},
+		},
+		{
+			desc:   "actual request from disallowed origin varies by origin when origins listed",
+			rule:   listed,
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.com",
+			},
+			vary: []string{"Origin"},
+		},
+		{
+			desc:   "actual request without origin varies by origin when origins listed",
+			rule:   listed,
+			method: http.MethodPut,
+			vary:   []string{"Origin"},
+		},
+		{
+			desc:   "actual request does not vary when any origin allowed",
+			rule:   anyOrigin,
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+		},
+		{
+			desc:   "preflight varies by origin and requested method and headers",
+			rule:   listed,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "rejected preflight varies by origin and requested method and headers",
+			rule:   listed,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "preflight varies by origin and requested method and headers when any origin allowed",
+			rule:   anyOrigin,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "preflight without origin varies when any origin allowed",
+			rule:   anyOrigin,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Access-Control-Request-Method": "PUT",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "preflight without origin varies when origins listed",
+			rule:   listed,
+			method: http.MethodOptions,
+			vary:   []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "preflight from disallowed origin varies by origin and requested method and headers",
+			rule:   listed,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://foo.com",
+				"Access-Control-Request-Method": "PUT",
+			},
+			vary: []string{"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		},
+		{
+			desc:   "preflight varies by private network request when private network allowed",
+			rule:   cors.NewRuleBuilder().WithMethods(http.MethodPut).WithPrivateNetwork().Build(),
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://foo.bar.org",
+				"Access-Control-Request-Method": "PUT",
+			},
+			vary: []string{
+				"Origin, Access-Control-Request-Method, Access-Control-Request-Headers, Access-Control-Request-Private-Network",
+			},
+		},
+		{
+			desc:   "keeps values set by the next handler",
+			rule:   listed,
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+			next: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Vary", "Accept-Encoding")
+				fmt.Fprint(w, "OK")
+			},
+			vary: []string{"Accept-Encoding", "Origin"},
+		},
+		{
+			desc:   "does not duplicate values set by the next handler",
+			rule:   listed,
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+			next: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Vary", "accept-encoding, origin")
+				w.WriteHeader(http.StatusCreated)
+			},
+			vary: []string{"accept-encoding, origin"},
+		},
+		{
+			desc:   "does not add values when the next handler varies by everything",
+			rule:   listed,
+			method: http.MethodPut,
+			headers: map[string]string{
+				"Origin": "https://foo.bar.org",
+			},
+			next: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Vary", "*")
+			},
+			vary: []string{"*"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			next := tC.next
+			if next == nil {
+				next = func(w http.ResponseWriter, r *http.Request) {}
+			}
+			h := cors.Middleware("/a", tC.rule)(next)
+
+			req := httptest.NewRequest(tC.method, "/a", nil)
+			for k, v := range tC.headers {
+				req.Header.Set(k, v)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			assert.Equal(t, tC.vary, res.Header.Values("Vary"))
+		})
+	}
+}
+
+// cache is a shared cache storing responses by path and request headers
+// listed in the response Vary header.
+type cache struct {
+	h       http.Handler
+	entries map[string][]cacheEntry
+	hits    int
+}
+
+type cacheEntry struct {
+	vary    []string
+	reqVals []string
+	res     *http.Response
+	body    string
+}
+
+func newCache(h http.Handler) *cache {
+	return &cache{h: h, entries: make(map[string][]cacheEntry)}
+}
+
+func (c *cache) do(t *testing.T, req *http.Request) (*http.Response, string) {
+	key := req.Method + " " + req.URL.Path
+
+	for _, e := range c.entries[key] {
+		if equalValues(e.reqVals, varyValues(req, e.vary)) {
+			c.hits++
+			return e.res, e.body
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	c.h.ServeHTTP(rr, req)
+
+	res := rr.Result()
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	var vary []string
+	for _, v := range res.Header.Values("Vary") {
+		for _, vv := range strings.Split(v, ",") {
+			vary = append(vary, strings.TrimSpace(vv))
+		}
+	}
+
+	c.entries[key] = append(c.entries[key], cacheEntry{
+		vary:    vary,
+		reqVals: varyValues(req, vary),
+		res:     res,
+		body:    string(body),
+	})
+
+	return res, string(body)
+}
+
+func varyValues(req *http.Request, vary []string) []string {
+	vals := make([]string, 0, len(vary))
+	for _, v := range vary {
+		vals = append(vals, req.Header.Get(v))
+	}
+	return vals
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVaryCacheCorrectness(t *testing.T) {
+	rule := cors.NewRuleBuilder().
+		WithOrigins("https://foo.bar.org", "https://bar.foo.org").
+		WithHeaders("content-type").
+		WithMethods(http.MethodGet, http.MethodPut).
+		Build()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "Accept-Encoding")
+		fmt.Fprint(w, "OK")
+	})
+	c := newCache(cors.Middleware("/a", rule)(next))
+
+	request := func(method, origin string, headers ...string) *http.Request {
+		req := httptest.NewRequest(method, "/a", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return req
+	}
+
+	// actual requests
+	res, _ := c.do(t, request(http.MethodGet, "https://foo.bar.org"))
+	assert.Equal(t, "https://foo.bar.org", res.Header.Get("Access-Control-Allow-Origin"))
+
+	res, _ = c.do(t, request(http.MethodGet, "https://bar.foo.org"))
+	assert.Equal(t, "https://bar.foo.org", res.Header.Get("Access-Control-Allow-Origin"))
+
+	res, _ = c.do(t, request(http.MethodGet, ""))
+	assert.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+
+	res, body := c.do(t, request(http.MethodGet, "https://foo.bar.org"))
+	assert.Equal(t, "https://foo.bar.org", res.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "OK", body)
+	assert.Equal(t, 1, c.hits)
+
+	// preflight requests
+	res, _ = c.do(t, request(http.MethodOptions, "https://foo.bar.org",
+		"Access-Control-Request-Method", "PUT",
+		"Access-Control-Request-Headers", "content-type"))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "PUT", res.Header.Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", res.Header.Get("Access-Control-Allow-Headers"))
+
+	res, _ = c.do(t, request(http.MethodOptions, "https://foo.bar.org",
+		"Access-Control-Request-Method", "PUT",
+		"Access-Control-Request-Headers", "x-correlation-id"))
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res, _ = c.do(t, request(http.MethodOptions, "https://foo.bar.org",
+		"Access-Control-Request-Method", "DELETE"))
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, _ = c.do(t, request(http.MethodOptions, "https://bar.foo.org",
+		"Access-Control-Request-Method", "PUT",
+		"Access-Control-Request-Headers", "content-type"))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "https://bar.foo.org", res.Header.Get("Access-Control-Allow-Origin"))
+
+	res, _ = c.do(t, request(http.MethodOptions, "https://foo.bar.org",
+		"Access-Control-Request-Method", "PUT",
+		"Access-Control-Request-Headers", "content-type"))
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "https://foo.bar.org", res.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, 2, c.hits)
+}
+
+func TestMergeVary(t *testing.T) {
+	testCases := []struct {
+		value  string
+		values []string
+		want   string
+	}{
+		{values: []string{"Origin"}, want: "Origin"},
+		{value: "Accept-Encoding", values: []string{"Origin"}, want: "Accept-Encoding, Origin"},
+		{value: "accept-encoding, origin", values: []string{"Origin, Access-Control-Request-Method"},
+			want: "accept-encoding, origin, Access-Control-Request-Method"},
+		{value: "*", values: []string{"Origin"}, want: "*"},
+		{value: "Accept-Encoding", want: "Accept-Encoding"},
+		{},
+	}
+	for _, tC := range testCases {
+		assert.Equal(t, tC.want, cors.MergeVary(tC.value, tC.values...), tC.value)
+	}
+}
+
+func TestVaryHijack(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		origins []string
+	}{
+		{
+			desc:    "hijacks connection when response varies",
+			origins: []string{"https://foo.bar.org"},
+		},
+		{
+			desc:    "hijacks connection when response does not vary",
+			origins: []string{"*"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, ok := w.(io.ReaderFrom)
+				assert.True(t, ok)
+
+				hj, ok := w.(http.Hijacker)
+				if !assert.True(t, ok) {
+					return
+				}
+
+				conn, buf, err := hj.Hijack()
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer conn.Close()
+
+				buf.WriteString("HTTP/1.1 418 I'm a teapot\r\nContent-Length: 6\r\nConnection: close\r\n\r\nteapot")
+				buf.Flush()
+			})
+
+			rule := cors.NewRuleBuilder().WithOrigins(tC.origins...).WithMethods(http.MethodGet).Build()
+			ts := httptest.NewServer(cors.Middleware("/a", rule)(next))
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL+"/a", nil)
+			require.NoError(t, err)
+			req.Header.Set("Origin", "https://foo.bar.org")
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, http.StatusTeapot, res.StatusCode)
+			assert.Equal(t, "teapot", string(body))
+		})
+	}
+}
+
+func TestVaryCacheAnyOriginPreflight(t *testing.T) {
+	rule := cors.NewRuleBuilder().WithOrigins("*").WithMethods(http.MethodPut).Build()
+	c := newCache(cors.Middleware("/a", rule)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	// OPTIONS request without CORS headers is answered without them
+	res, _ := c.do(t, httptest.NewRequest(http.MethodOptions, "/a", nil))
+	assert.Empty(t, res.Header.Get("Access-Control-Allow-Origin"))
+
+	req := httptest.NewRequest(http.MethodOptions, "/a", nil)
+	req.Header.Set("Origin", "https://foo.bar.org")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	res, _ = c.do(t, req)
+	assert.Equal(t, "*", res.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "PUT", res.Header.Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, 0, c.hits)
+}